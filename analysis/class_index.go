@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+
+	"gdx/analysis/lexer"
+	"gdx/analysis/parser"
+)
+
+// maps the names scripts give themselves with class_name to the res:// paths of the scripts
+type ClassIndex struct {
+	paths map[string]string
+}
+
+func NewClassIndex() *ClassIndex {
+	return &ClassIndex{paths: make(map[string]string)}
+}
+
+func (i *ClassIndex) Add(name string, path string) {
+	i.paths[name] = path
+}
+
+// finds the res:// path of the script declaring a class_name
+func (i *ClassIndex) Path(name string) (string, bool) {
+	path, ok := i.paths[name]
+	return path, ok
+}
+
+func (i *ClassIndex) Len() int {
+	return len(i.paths)
+}
+
+// checks if a file is one of the files class names are read from
+func IsClassSource(filename string) bool {
+	return filepath.Ext(filename) == ".gd"
+}
+
+// finds the scripts in a project that declare a class_name. The directories Godot
+// ignores are skipped like in BuildUIDIndex, and so are scripts that can't be read
+func BuildClassIndex(projectPath string) (*ClassIndex, error) {
+	index := NewClassIndex()
+
+	err := walkProject(projectPath, func(filename string, path string) {
+		if !IsClassSource(filename) {
+			return
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return
+		}
+
+		tokens, _ := lexer.NewScanner(string(data)).ScanTokens()
+		file, _ := parser.NewParser(tokens).Parse()
+
+		if file.ClassName != nil {
+			index.Add(file.ClassName.Name, path)
+		}
+	})
+
+	return index, err
+}
@@ -0,0 +1,33 @@
+package analysis_test
+
+import (
+	"gdx/analysis"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildClassIndex(t *testing.T) {
+	index, err := analysis.BuildClassIndex(filepath.Join("..", "testdata", "project"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "Item", path: "res://item.gd"},
+		{name: "Weapon", path: "res://weapon.gd"},
+		{name: "Shield", path: "res://shield.gd"},
+	}
+
+	for _, test := range tests {
+		if path, ok := index.Path(test.name); !ok || path != test.path {
+			t.Errorf("expected %s to be declared in %s, got %q", test.name, test.path, path)
+		}
+	}
+
+	if index.Len() != len(tests) {
+		t.Errorf("expected only scripts with a class_name to be indexed, got %d", index.Len())
+	}
+}
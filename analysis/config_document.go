@@ -0,0 +1,276 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gdx/analysis/variant"
+)
+
+// a ConfigFile that can be edited. Edits only change the text of the keys they touch
+// so comments, ordering and the formatting of every other value are kept as written
+type ConfigDocument struct {
+	source string
+	file   *ConfigFile
+}
+
+// parses a document for editing. Syntax errors are returned but the document can still
+// be edited, the keys that could be parsed are found as usual
+func NewConfigDocument(contents []byte) (*ConfigDocument, []*variant.SyntaxError) {
+	file, errors := ParseConfigFile(contents)
+
+	return &ConfigDocument{source: string(contents), file: file}, errors
+}
+
+// the parsed contents of the document after the edits so far
+func (d *ConfigDocument) File() *ConfigFile {
+	return d.file
+}
+
+// the text of the document after the edits so far
+func (d *ConfigDocument) Bytes() []byte {
+	return []byte(d.source)
+}
+
+// sets the value of a key. A key that is already set has its value replaced in place, a new
+// key is added to the end of its section and a new section is added in alphabetical order,
+// where Godot would put it
+func (d *ConfigDocument) Set(section string, key string, value any) error {
+	text, err := variant.Format(value)
+	if err != nil {
+		return err
+	}
+
+	if existing := d.lastKey(section, key); existing != nil {
+		d.replace(existing.ValueRange.Start.Offset, existing.ValueRange.End.Offset, text)
+		return nil
+	}
+
+	line := formatKey(key) + "=" + text + "\n"
+
+	var found *ConfigSection
+	for _, s := range d.file.Sections {
+		if s.Name == section {
+			found = s
+		}
+	}
+
+	switch {
+	case found != nil && len(found.Keys) > 0:
+		last := found.Keys[len(found.Keys)-1]
+		at := d.lineEnd(last.ValueRange.End.Offset)
+		d.replace(at, at, d.newlineBefore(at)+line)
+	case found != nil && section == "":
+		// the global section has no tag, its keys go before the first section
+		at := d.firstTagOffset()
+		d.replace(at, at, line+"\n")
+	case found != nil:
+		at := d.lineEnd(found.Range.End.Offset)
+		d.replace(at, at, d.newlineBefore(at)+"\n"+line)
+	default:
+		d.insertSection(section, line)
+	}
+
+	return nil
+}
+
+// removes every assignment of a key in the section along with any comment on the same line.
+// A section left without keys or comments is removed too. Returns false if the key wasn't set
+func (d *ConfigDocument) Delete(section string, key string) bool {
+	deleted := false
+
+	// keys are removed from the end so the offsets of the earlier ones stay valid
+	for i := len(d.file.Sections) - 1; i >= 0; i-- {
+		s := d.file.Sections[i]
+		if s.Name != section {
+			continue
+		}
+
+		for j := len(s.Keys) - 1; j >= 0; j-- {
+			k := s.Keys[j]
+			if k.Name != key {
+				continue
+			}
+
+			start := d.lineStart(k.NameRange.Start.Offset)
+			end := d.lineEnd(k.ValueRange.End.Offset)
+			d.source = d.source[:start] + d.source[end:]
+			deleted = true
+		}
+	}
+
+	if !deleted {
+		return false
+	}
+
+	d.reparse()
+	d.removeEmptySections(section)
+
+	return true
+}
+
+// writes the document to a file, replacing it only once the whole document has been written
+func (d *ConfigDocument) Save(filename string) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	temporary, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temporary.Name())
+
+	if _, err := temporary.WriteString(d.source); err != nil {
+		temporary.Close()
+		return err
+	}
+
+	if err := temporary.Chmod(mode); err != nil {
+		temporary.Close()
+		return err
+	}
+
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temporary.Name(), filename)
+}
+
+// replaces the text between two offsets and parses the document again so the ranges are up to date
+func (d *ConfigDocument) replace(start int, end int, text string) {
+	d.source = d.source[:start] + text + d.source[end:]
+	d.reparse()
+}
+
+func (d *ConfigDocument) reparse() {
+	d.file, _ = ParseConfigFile([]byte(d.source))
+}
+
+// the key that is used when it's set more than once
+func (d *ConfigDocument) lastKey(section string, key string) *ConfigKey {
+	var found *ConfigKey
+	for _, s := range d.file.Sections {
+		if s.Name != section {
+			continue
+		}
+
+		if k := s.Key(key); k != nil {
+			found = k
+		}
+	}
+
+	return found
+}
+
+// adds a new section before the first section that comes after it alphabetically
+func (d *ConfigDocument) insertSection(section string, line string) {
+	tag := "[" + section + "]\n\n" + line
+
+	for _, s := range d.file.Sections {
+		if s.Name != "" && s.Name > section {
+			at := d.lineStart(s.Range.Start.Offset)
+			d.replace(at, at, tag+"\n")
+			return
+		}
+	}
+
+	// sections are separated by a blank line
+	end := len(d.source)
+	separator := ""
+	switch {
+	case end == 0:
+	case strings.HasSuffix(d.source, "\n\n"):
+	case strings.HasSuffix(d.source, "\n"):
+		separator = "\n"
+	default:
+		separator = "\n\n"
+	}
+
+	d.replace(end, end, separator+tag)
+}
+
+// removes the sections with the name that have no keys left, as long as nothing but
+// blank lines follows their tag
+func (d *ConfigDocument) removeEmptySections(section string) {
+	for i := len(d.file.Sections) - 1; i >= 0; i-- {
+		s := d.file.Sections[i]
+		if s.Name != section || s.Name == "" || len(s.Keys) > 0 {
+			continue
+		}
+
+		start := d.lineStart(s.Range.Start.Offset)
+		end := len(d.source)
+		if i+1 < len(d.file.Sections) {
+			end = d.lineStart(d.file.Sections[i+1].Range.Start.Offset)
+		}
+
+		if strings.TrimSpace(d.source[d.lineEnd(s.Range.End.Offset):end]) != "" {
+			continue
+		}
+
+		if end == len(d.source) && start > 0 {
+			// the blank line that separated the last section goes with it
+			d.source = strings.TrimRight(d.source[:start], "\n") + "\n"
+			continue
+		}
+
+		d.source = d.source[:start] + d.source[end:]
+	}
+
+	d.reparse()
+}
+
+func (d *ConfigDocument) firstTagOffset() int {
+	for _, s := range d.file.Sections {
+		if s.Name != "" {
+			return d.lineStart(s.Range.Start.Offset)
+		}
+	}
+
+	return len(d.source)
+}
+
+// the offset of the start of the line containing offset
+func (d *ConfigDocument) lineStart(offset int) int {
+	return strings.LastIndexByte(d.source[:offset], '\n') + 1
+}
+
+// the offset after the newline ending the line containing offset, or the end of the document
+func (d *ConfigDocument) lineEnd(offset int) int {
+	end := strings.IndexByte(d.source[offset:], '\n')
+	if end == -1 {
+		return len(d.source)
+	}
+
+	return offset + end + 1
+}
+
+// a newline to add before inserting text at offset when the last line isn't finished
+func (d *ConfigDocument) newlineBefore(offset int) string {
+	if offset > 0 && d.source[offset-1] != '\n' {
+		return "\n"
+	}
+
+	return ""
+}
+
+// keys that are more than a path are quoted
+func formatKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("_/.-+", c) != -1) {
+			quoted, _ := variant.Format(key)
+			return quoted
+		}
+	}
+
+	if key == "" {
+		return `""`
+	}
+
+	return key
+}
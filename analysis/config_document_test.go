@@ -0,0 +1,229 @@
+package analysis_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gdx/analysis"
+	"gdx/analysis/variant"
+)
+
+const editableProject = `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Game" ; shown in the title bar
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+`
+
+func TestConfigDocumentEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(document *analysis.ConfigDocument) error
+		expected string
+	}{
+		{
+			name: "Replace a value",
+			edit: func(document *analysis.ConfigDocument) error {
+				return document.Set("application", "config/name", "Platformer")
+			},
+			expected: `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Platformer" ; shown in the title bar
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+`,
+		},
+		{
+			name: "Replace a multi-line value",
+			edit: func(document *analysis.ConfigDocument) error {
+				return document.Set("input", "jump", variant.Dictionary{{Key: "deadzone", Value: 0.2}, {Key: "events", Value: variant.Array{}}})
+			},
+			expected: `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Game" ; shown in the title bar
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+
+[input]
+
+jump={
+"deadzone": 0.2,
+"events": []
+}
+`,
+		},
+		{
+			name: "Add a key",
+			edit: func(document *analysis.ConfigDocument) error {
+				return document.Set("application", "run/main_scene", "res://main.tscn")
+			},
+			expected: `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Game" ; shown in the title bar
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+run/main_scene="res://main.tscn"
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+`,
+		},
+		{
+			name: "Add sections in order",
+			edit: func(document *analysis.ConfigDocument) error {
+				if err := document.Set("autoload", "Globals", "*res://globals.gd"); err != nil {
+					return err
+				}
+				return document.Set("rendering", "renderer/rendering_method", "gl_compatibility")
+			},
+			expected: `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Game" ; shown in the title bar
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+
+[autoload]
+
+Globals="*res://globals.gd"
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+
+[rendering]
+
+renderer/rendering_method="gl_compatibility"
+`,
+		},
+		{
+			name: "Delete a key and its empty section",
+			edit: func(document *analysis.ConfigDocument) error {
+				if !document.Delete("input", "jump") {
+					t.Error("expected jump to be deleted")
+				}
+				if document.Delete("input", "jump") {
+					t.Error("expected jump to already be deleted")
+				}
+				return nil
+			},
+			expected: `; Engine configuration file.
+
+config_version=5
+
+[application]
+
+config/name="Game" ; shown in the title bar
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+`,
+		},
+		{
+			name: "Delete a key with a comment",
+			edit: func(document *analysis.ConfigDocument) error {
+				document.Delete("application", "config/name")
+				return document.Set("", "config_version", int64(6))
+			},
+			expected: `; Engine configuration file.
+
+config_version=6
+
+[application]
+
+config/features=PackedStringArray("4.4",   "GL Compatibility")
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, errors := analysis.NewConfigDocument([]byte(editableProject))
+			if len(errors) > 0 {
+				t.Fatalf("unexpected errors: %v", errors)
+			}
+
+			if err := test.edit(document); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if actual := string(document.Bytes()); actual != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConfigDocumentSave(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "project.godot")
+	if err := os.WriteFile(filename, []byte("[application]\n\nconfig/name=\"Game\""), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	document, _ := analysis.NewConfigDocument([]byte("[application]\n\nconfig/name=\"Game\""))
+	if err := document.Set("application", "config/icon", "res://icon.svg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := document.Set("display", "window/stretch/scale", 2.0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := document.Save(filename); err != nil {
+		t.Fatalf("unable to save: %v", err)
+	}
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[application]\n\nconfig/name=\"Game\"\nconfig/icon=\"res://icon.svg\"\n\n[display]\n\nwindow/stretch/scale=2.0\n"
+	if string(contents) != expected {
+		t.Errorf("expected %q, got %q", expected, string(contents))
+	}
+
+	if info, err := os.Stat(filename); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the file mode to be kept, got %v", info.Mode())
+	}
+}
@@ -0,0 +1,123 @@
+package analysis
+
+import "gdx/analysis/variant"
+
+// a file in Godot's ConfigFile format such as project.godot. Sections and
+// keys keep the order they were written in
+type ConfigFile struct {
+	Sections []*ConfigSection
+}
+
+type ConfigSection struct {
+	// empty for the keys before the first section
+	Name string
+	// the range of the [section] tag
+	Range variant.Range
+	Keys  []*ConfigKey
+}
+
+type ConfigKey struct {
+	Name       string
+	Value      any
+	NameRange  variant.Range
+	ValueRange variant.Range
+}
+
+// parses a ConfigFile with the values converted to Go types by the variant
+// parser. Syntax errors don't stop parsing, the keys that could be parsed are
+// still returned
+func ParseConfigFile(contents []byte) (*ConfigFile, []*variant.SyntaxError) {
+	entries, errors := variant.ParseFile(string(contents))
+
+	file := &ConfigFile{Sections: make([]*ConfigSection, 0)}
+	section := &ConfigSection{Keys: make([]*ConfigKey, 0)}
+	file.Sections = append(file.Sections, section)
+
+	for _, entry := range entries {
+		if entry.Tag != nil {
+			section = &ConfigSection{
+				Name:  entry.Tag.Name,
+				Range: entry.Tag.Range,
+				Keys:  make([]*ConfigKey, 0),
+			}
+			file.Sections = append(file.Sections, section)
+			continue
+		}
+
+		section.Keys = append(section.Keys, &ConfigKey{
+			Name:       entry.Assignment.Key,
+			Value:      entry.Assignment.Value,
+			NameRange:  entry.Assignment.KeyRange,
+			ValueRange: entry.Assignment.ValueRange,
+		})
+	}
+
+	return file, errors
+}
+
+// finds a section by its name, a section written more than once is returned
+// the first time it appears
+func (f *ConfigFile) Section(name string) *ConfigSection {
+	for _, section := range f.Sections {
+		if section.Name == name {
+			return section
+		}
+	}
+
+	return nil
+}
+
+// finds the value of a key in a section. When a key is set more than once the last value is used
+func (f *ConfigFile) Get(section string, key string) (any, bool) {
+	var found *ConfigKey
+	for _, s := range f.Sections {
+		if s.Name != section {
+			continue
+		}
+
+		if k := s.Key(key); k != nil {
+			found = k
+		}
+	}
+
+	if found == nil {
+		return nil, false
+	}
+
+	return found.Value, true
+}
+
+// finds a key in the section, when it's set more than once the last one is returned
+func (s *ConfigSection) Key(name string) *ConfigKey {
+	var found *ConfigKey
+	for _, key := range s.Keys {
+		if key.Name == name {
+			found = key
+		}
+	}
+
+	return found
+}
+
+// finds the value of a key and converts it to T. Ints are converted to floats when
+// T is float64 since whole floats can be written without a decimal point
+func ConfigValue[T any](f *ConfigFile, section string, key string) (T, bool) {
+	var zero T
+
+	value, ok := f.Get(section, key)
+	if !ok {
+		return zero, false
+	}
+
+	if result, ok := value.(T); ok {
+		return result, true
+	}
+
+	if integer, ok := value.(int64); ok {
+		if result, ok := any(float64(integer)).(T); ok {
+			return result, true
+		}
+	}
+
+	return zero, false
+}
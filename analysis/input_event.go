@@ -0,0 +1,265 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"gdx/analysis/variant"
+)
+
+// an input event bound to an action in project.godot
+type InputEvent interface {
+	// a human readable name for the event, such as "Ctrl+W" or "Joypad Button 0"
+	String() string
+}
+
+// the modifier keys held with a key or mouse button
+type Modifiers struct {
+	Ctrl  bool
+	Shift bool
+	Alt   bool
+	Meta  bool
+	// Ctrl on Windows and Linux, Command on macOS
+	CommandOrControl bool
+}
+
+func (m Modifiers) prefix() string {
+	var result strings.Builder
+
+	if m.CommandOrControl {
+		result.WriteString("Command/Ctrl+")
+	} else if m.Ctrl {
+		result.WriteString("Ctrl+")
+	}
+	if m.Shift {
+		result.WriteString("Shift+")
+	}
+	if m.Alt {
+		result.WriteString("Alt+")
+	}
+	if m.Meta {
+		result.WriteString("Meta+")
+	}
+
+	return result.String()
+}
+
+type InputEventKey struct {
+	Modifiers
+	Keycode         int64
+	PhysicalKeycode int64
+	KeyLabel        int64
+	Unicode         int64
+}
+
+// the key is named after the first code that is set, in the order Godot matches them
+func (e InputEventKey) String() string {
+	for _, code := range []int64{e.Keycode, e.PhysicalKeycode, e.KeyLabel, e.Unicode} {
+		if code != 0 {
+			return e.prefix() + keyName(code)
+		}
+	}
+
+	return e.prefix() + "(Unset)"
+}
+
+type InputEventMouseButton struct {
+	Modifiers
+	ButtonIndex int64
+	DoubleClick bool
+}
+
+var mouseButtonNames = map[int64]string{
+	1: "Left Mouse Button",
+	2: "Right Mouse Button",
+	3: "Middle Mouse Button",
+	4: "Mouse Wheel Up",
+	5: "Mouse Wheel Down",
+	6: "Mouse Wheel Left",
+	7: "Mouse Wheel Right",
+	8: "Mouse Thumb Button 1",
+	9: "Mouse Thumb Button 2",
+}
+
+func (e InputEventMouseButton) String() string {
+	name, ok := mouseButtonNames[e.ButtonIndex]
+	if !ok {
+		name = fmt.Sprintf("Mouse Button %d", e.ButtonIndex)
+	}
+
+	if e.DoubleClick {
+		name += " (Double Click)"
+	}
+
+	return e.prefix() + name
+}
+
+type InputEventJoypadButton struct {
+	ButtonIndex int64
+}
+
+func (e InputEventJoypadButton) String() string {
+	return fmt.Sprintf("Joypad Button %d", e.ButtonIndex)
+}
+
+type InputEventJoypadMotion struct {
+	Axis      int64
+	AxisValue float64
+}
+
+// the direction of the axis is written after it, such as "Joypad Axis 1 -" for up on the left stick
+func (e InputEventJoypadMotion) String() string {
+	direction := "+"
+	if e.AxisValue < 0 {
+		direction = "-"
+	}
+
+	return fmt.Sprintf("Joypad Axis %d %s", e.Axis, direction)
+}
+
+// decodes an input event saved in project.godot. Other event classes can't be bound
+// to actions from the editor so they are skipped
+func decodeInputEvent(object variant.Object) (InputEvent, bool) {
+	switch object.Class {
+	case "InputEventKey":
+		return InputEventKey{
+			Modifiers:       decodeModifiers(object),
+			Keycode:         intProperty(object, "keycode"),
+			PhysicalKeycode: intProperty(object, "physical_keycode"),
+			KeyLabel:        intProperty(object, "key_label"),
+			Unicode:         intProperty(object, "unicode"),
+		}, true
+	case "InputEventMouseButton":
+		return InputEventMouseButton{
+			Modifiers:   decodeModifiers(object),
+			ButtonIndex: intProperty(object, "button_index"),
+			DoubleClick: boolProperty(object, "double_click"),
+		}, true
+	case "InputEventJoypadButton":
+		return InputEventJoypadButton{
+			ButtonIndex: intProperty(object, "button_index"),
+		}, true
+	case "InputEventJoypadMotion":
+		return InputEventJoypadMotion{
+			Axis:      intProperty(object, "axis"),
+			AxisValue: floatProperty(object, "axis_value"),
+		}, true
+	}
+
+	return nil, false
+}
+
+func decodeModifiers(object variant.Object) Modifiers {
+	return Modifiers{
+		Ctrl:             boolProperty(object, "ctrl_pressed"),
+		Shift:            boolProperty(object, "shift_pressed"),
+		Alt:              boolProperty(object, "alt_pressed"),
+		Meta:             boolProperty(object, "meta_pressed"),
+		CommandOrControl: boolProperty(object, "command_or_control_autoremap"),
+	}
+}
+
+func intProperty(object variant.Object, name string) int64 {
+	value, _ := object.Get(name)
+	result, _ := value.(int64)
+	return result
+}
+
+func boolProperty(object variant.Object, name string) bool {
+	value, _ := object.Get(name)
+	result, _ := value.(bool)
+	return result
+}
+
+func floatProperty(object variant.Object, name string) float64 {
+	value, _ := object.Get(name)
+	return toFloat(value)
+}
+
+// converts an int or float value to a float, Godot writes whole floats without a decimal point
+func toFloat(value any) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case int64:
+		return float64(value)
+	}
+
+	return 0
+}
+
+// keys that aren't printable characters have this bit set in their keycode
+const keySpecial = 1 << 22
+
+var specialKeyNames = map[int64]string{
+	0x01: "Escape",
+	0x02: "Tab",
+	0x03: "Backtab",
+	0x04: "Backspace",
+	0x05: "Enter",
+	0x06: "Kp Enter",
+	0x07: "Insert",
+	0x08: "Delete",
+	0x09: "Pause",
+	0x0A: "Print",
+	0x0B: "SysReq",
+	0x0C: "Clear",
+	0x0D: "Home",
+	0x0E: "End",
+	0x0F: "Left",
+	0x10: "Up",
+	0x11: "Right",
+	0x12: "Down",
+	0x13: "PageUp",
+	0x14: "PageDown",
+	0x15: "Shift",
+	0x16: "Ctrl",
+	0x17: "Meta",
+	0x18: "Alt",
+	0x19: "CapsLock",
+	0x1A: "NumLock",
+	0x1B: "ScrollLock",
+	0x42: "Menu",
+	0x43: "Hyper",
+	0x45: "Help",
+	0x48: "Back",
+	0x49: "Forward",
+	0x4A: "Stop",
+	0x4B: "Refresh",
+	0x81: "Kp Multiply",
+	0x82: "Kp Divide",
+	0x83: "Kp Subtract",
+	0x84: "Kp Period",
+	0x85: "Kp Add",
+}
+
+// names a Godot 4 keycode the way the editor does, such as "W", "Space" or "F1"
+func keyName(code int64) string {
+	// modifiers can be masked into the keycode
+	code &= keySpecial<<1 - 1
+
+	if code&keySpecial == 0 {
+		switch {
+		case code == ' ':
+			return "Space"
+		case code > ' ' && code != 0x7F:
+			return strings.ToUpper(string(rune(code)))
+		default:
+			return fmt.Sprintf("Unknown (%d)", code)
+		}
+	}
+
+	code &^= keySpecial
+	switch {
+	case code >= 0x1C && code <= 0x3E:
+		return fmt.Sprintf("F%d", code-0x1C+1)
+	case code >= 0x86 && code <= 0x8F:
+		return fmt.Sprintf("Kp %d", code-0x86)
+	}
+
+	if name, ok := specialKeyNames[code]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("Unknown (%d)", code|keySpecial)
+}
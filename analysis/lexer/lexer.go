@@ -2,8 +2,11 @@ package lexer
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 type TokenType = int
@@ -12,8 +15,12 @@ const (
 	// Literals
 	TokenIdentifier TokenType = iota
 	TokenString
-	TokenNumber
+	TokenInt
+	TokenFloat
 	TokenRawString
+	TokenStringName
+	TokenNodePath
+	TokenAnnotation
 
 	// Keywords
 	TokenIf
@@ -51,12 +58,24 @@ const (
 	TokenTAU
 	TokenINF
 	TokenNAN
+	TokenTrue
+	TokenFalse
+	TokenNull
+	TokenNamespace
+	TokenTrait
 
 	// Single Character Tokens
 	TokenLParen
 	TokenRParen
 	TokenLBracket
 	TokenRBracket
+	TokenLBrace
+	TokenRBrace
+	TokenColon
+	TokenSemicolon
+	TokenDollar
+	TokenQuestionMark
+	TokenBacktick
 	TokenPeriod
 	TokenTilda
 	TokenDash
@@ -94,6 +113,18 @@ const (
 	TokenRShiftEqual
 	TokenLShiftEqual
 	TokenPower
+	TokenArrow
+	TokenColonEquals
+	TokenPeriodPeriod
+	TokenEllipsis
+
+	// Layout
+	TokenNewline
+	TokenIndent
+	TokenDedent
+
+	// source text that couldn't be scanned, a LexicalError is reported for it
+	TokenUnknown
 
 	TokenEOF
 )
@@ -134,53 +165,110 @@ var GDScriptKeywords = map[string]TokenType{
 	"TAU":        TokenTAU,
 	"INF":        TokenINF,
 	"NAN":        TokenNAN,
+	"true":       TokenTrue,
+	"false":      TokenFalse,
+	"null":       TokenNull,
+	"namespace":  TokenNamespace,
+	"trait":      TokenTrait,
+	// keyword operators share token types with their symbol forms
+	"and": TokenBooleanAnd,
+	"or":  TokenBooleanOr,
+	"not": TokenBang,
+}
+
+// the width of a tab when measuring indentation, matching Godot's default
+const tabSize = 4
+
+func isAlpha(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
 }
 
 func isAlphaNumeric(c rune) bool {
-	return unicode.IsDigit(c) || unicode.IsLetter(c)
+	return unicode.IsDigit(c) || isAlpha(c)
+}
+
+// a location in the source. Lines start at 1, columns are byte offsets into
+// the line starting at 0 and the offset is the byte offset into the whole source
+type Position struct {
+	Line   int
+	Column int
+	Offset int
 }
 
 type LexicalError struct {
-	Line      int
-	StartChar int
-	EndChar   int
-	Message   string
+	Start   Position
+	End     Position
+	Message string
 }
 
-func NewLexicalError(line int, startChar int, endChar int, message string) *LexicalError {
+func NewLexicalError(start Position, end Position, message string) *LexicalError {
 	return &LexicalError{
-		Line:      line,
-		StartChar: startChar,
-		EndChar:   endChar,
-		Message:   message,
+		Start:   start,
+		End:     end,
+		Message: message,
 	}
 }
 
 func (s LexicalError) Error() string {
-	return fmt.Sprintf("lexical error at line %d, char %d: %s", s.Line, s.StartChar, s.Message)
+	return fmt.Sprintf("lexical error at line %d, char %d: %s", s.Start.Line, s.Start.Column, s.Message)
 }
 
 type Token struct {
 	Type  TokenType
 	Value string
+	// the exact source text of the token
+	Lexeme string
+	// the value of a literal token, an int64 for TokenInt, a float64 for TokenFloat
+	// and the decoded string for strings, StringNames and NodePaths
+	Literal any
+	// the line the token starts on, the same as Start.Line
 	Line  int
+	Start Position
+	End   Position
+	// trivia before the token, and after it on the same line
+	Leading  []Trivia
+	Trailing []Trivia
+}
+
+// a lambda whose body is laid out as an indented block while inside brackets
+type lambdaBlock struct {
+	depth  int
+	indent int
 }
 
 type Scanner struct {
-	source  string
-	start   int
-	current int
-	line    int
-	tokens  []Token
+	source    string
+	start     int
+	current   int
+	line      int
+	lineStart int
+	startPos  Position
+	tokens    []Token
+	errors    []*LexicalError
+	// the end of the last token, where the next token's trivia starts
+	lastEnd Position
+
+	// indentation tracking
+	atLineStart bool
+	indentChar  rune
+	indents     []int
+	depth       int
+	lambdas     []lambdaBlock
+	lambdaDepth int
 }
 
 func NewScanner(source string) *Scanner {
 	return &Scanner{
-		source:  source,
-		start:   0,
-		current: 0,
-		line:    1,
-		tokens:  make([]Token, 0),
+		source:      source,
+		start:       0,
+		current:     0,
+		line:        1,
+		lastEnd:     Position{Line: 1},
+		tokens:      make([]Token, 0),
+		errors:      make([]*LexicalError, 0),
+		atLineStart: true,
+		indents:     []int{0},
+		lambdaDepth: -1,
 	}
 }
 
@@ -189,10 +277,30 @@ func (s *Scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
 
-// advance the scanner
+// the position of the scanner in the source
+func (s *Scanner) pos() Position {
+	return Position{
+		Line:   s.line,
+		Column: s.current - s.lineStart,
+		Offset: s.current,
+	}
+}
+
+// marks the current position as the start of the next token
+func (s *Scanner) startToken() {
+	s.start = s.current
+	s.startPos = s.pos()
+}
+
+// advance the scanner by one UTF-8 encoded character, keeping track of lines
 func (s *Scanner) advance() rune {
-	char := rune(s.source[s.current])
-	s.current += 1
+	char, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += size
+
+	if char == '\n' {
+		s.line += 1
+		s.lineStart = s.current
+	}
 
 	return char
 }
@@ -201,20 +309,49 @@ func (s *Scanner) advance() rune {
 func (s *Scanner) addToken(tokenType TokenType) {
 	text := s.source[s.start:s.current]
 
-	s.tokens = append(s.tokens, Token{
-		Type:  tokenType,
-		Value: text,
-		Line:  s.line,
-	})
+	s.addTokenWithValue(tokenType, text)
 }
 
 func (s *Scanner) addTokenWithValue(tokenType TokenType, value string) {
-	s.tokens = append(s.tokens, Token{
-		Type:  tokenType,
-		Value: value,
-		Line:  s.line,
+	s.addLiteralToken(tokenType, value, nil)
+}
+
+func (s *Scanner) addLiteralToken(tokenType TokenType, value string, literal any) {
+	s.appendToken(Token{
+		Type:    tokenType,
+		Value:   value,
+		Literal: literal,
+		Line:    s.startPos.Line,
+		Start:   s.startPos,
+		End:     s.pos(),
 	})
+}
+
+// adds a token to the scanned tokens, attaching the trivia since the previous token.
+// Trivia on the same line as the previous token trails it, the rest leads the new token
+func (s *Scanner) appendToken(token Token) {
+	token.Lexeme = s.source[token.Start.Offset:token.End.Offset]
+	trivia := splitTrivia(s.source[s.lastEnd.Offset:token.Start.Offset], s.lastEnd)
+
+	if len(s.tokens) > 0 && !isLayout(s.lastTokenType()) {
+		previous := &s.tokens[len(s.tokens)-1]
 
+		for len(trivia) > 0 && trivia[0].Type != TriviaNewline {
+			previous.Trailing = append(previous.Trailing, trivia[0])
+			trivia = trivia[1:]
+		}
+	}
+
+	if len(trivia) > 0 {
+		token.Leading = trivia
+	}
+
+	s.tokens = append(s.tokens, token)
+	s.lastEnd = token.End
+}
+
+func isLayout(tokenType TokenType) bool {
+	return tokenType == TokenNewline || tokenType == TokenIndent || tokenType == TokenDedent
 }
 
 // check is the next character equals the given character
@@ -223,11 +360,11 @@ func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
-	if rune(s.source[s.current]) != expected {
+	if s.peek() != expected {
 		return false
 	}
 
-	s.current += 1
+	s.advance()
 	return true
 }
 
@@ -236,93 +373,570 @@ func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return '\x00'
 	}
-	return rune(s.source[s.current])
+
+	char, _ := utf8.DecodeRuneInString(s.source[s.current:])
+	return char
 }
 
 // peeks at the next next character (two characters ahead)
 func (s *Scanner) peekNext() rune {
-	if s.current+1 >= len(s.source) {
+	if s.isAtEnd() {
+		return '\x00'
+	}
+
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+	if s.current+size >= len(s.source) {
 		return '\x00'
 	}
-	return rune(s.source[s.current+1])
+
+	char, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+	return char
+}
+
+// checks if newlines and indentation are significant at the current position.
+// They are ignored inside brackets unless we are inside a lambda block
+func (s *Scanner) layoutActive() bool {
+	if s.depth == 0 {
+		return true
+	}
+
+	_, ok := s.currentLambda()
+	return ok
+}
+
+func (s *Scanner) lastTokenType() TokenType {
+	if len(s.tokens) == 0 {
+		return TokenEOF
+	}
+
+	return s.tokens[len(s.tokens)-1].Type
+}
+
+// records a lexical error and carries on scanning
+func (s *Scanner) addError(start Position, end Position, message string) {
+	s.errors = append(s.errors, NewLexicalError(start, end, message))
 }
 
-func (s *Scanner) makeString(c rune, t TokenType) *LexicalError {
-	if s.current+2 < len(s.source) && // Check if we have enough characters ahead
-		rune(s.source[s.current]) == c &&
-		rune(s.source[s.current+1]) == c { // Check for triple-quoted strings
-		// Advance past the opening quotes
+// records a lexical error for the current token and emits it as an unknown token
+// so later stages can still see that something was there
+func (s *Scanner) addErrorToken(message string) {
+	s.addError(s.startPos, s.pos(), message)
+	s.addTokenWithValue(TokenUnknown, s.source[s.startPos.Offset:s.current])
+}
+
+// emits a layout token which has no source text
+func (s *Scanner) addLayoutToken(tokenType TokenType, start Position, end Position) {
+	s.appendToken(Token{
+		Type:  tokenType,
+		Line:  start.Line,
+		Start: start,
+		End:   end,
+	})
+}
+
+// pops the indentation stack down to the given level, emitting a dedent at the position for each level
+func (s *Scanner) dedentTo(level int, at Position) {
+	for s.indents[len(s.indents)-1] > level {
+		s.indents = s.indents[:len(s.indents)-1]
+		s.addLayoutToken(TokenDedent, at, at)
+	}
+}
+
+// returns the innermost lambda block if the scanner is currently inside it
+func (s *Scanner) currentLambda() (lambdaBlock, bool) {
+	if len(s.lambdas) == 0 || s.lambdas[len(s.lambdas)-1].depth != s.depth {
+		return lambdaBlock{}, false
+	}
+
+	return s.lambdas[len(s.lambdas)-1], true
+}
+
+// leaves the innermost lambda block, closing any blocks opened inside of it
+func (s *Scanner) endLambda(at Position) {
+	lambda := s.lambdas[len(s.lambdas)-1]
+	s.lambdas = s.lambdas[:len(s.lambdas)-1]
+	s.dedentTo(lambda.indent, at)
+}
+
+// measures the indentation of a new line and emits indent/dedent tokens
+func (s *Scanner) scanIndentation() {
+	s.atLineStart = false
+	s.startToken()
+
+	width := 0
+	mixed := false
+	for s.peek() == ' ' || s.peek() == '\t' {
+		c := s.advance()
+		if c == '\t' {
+			width += tabSize
+		} else {
+			width += 1
+		}
+
+		if c != rune(s.source[s.start]) {
+			mixed = true
+		}
+	}
+
+	// blank lines and comment-only lines don't affect indentation
+	switch s.peek() {
+	case '\n', '\r', '#', '\x00':
+		return
+	}
+
+	if !s.layoutActive() {
+		return
+	}
+
+	// indentation errors are reported but the measured width is still used
+	if width > 0 {
+		// the first indented line decides which character the rest of the file uses
+		c := rune(s.source[s.start])
+
+		if mixed {
+			s.addError(s.startPos, s.pos(), "mixed use of tabs and spaces for indentation")
+		} else if s.indentChar == 0 {
+			s.indentChar = c
+		} else if c != s.indentChar {
+			if c == '\t' {
+				s.addError(s.startPos, s.pos(), "used tab character for indentation instead of space as used before in the file")
+			} else {
+				s.addError(s.startPos, s.pos(), "used space character for indentation instead of tab as used before in the file")
+			}
+		}
+	}
+
+	if width > s.indents[len(s.indents)-1] {
+		s.indents = append(s.indents, width)
+		s.addLayoutToken(TokenIndent, s.startPos, s.pos())
+		return
+	}
+
+	// dedenting back to the line a lambda was declared on ends its body
+	if lambda, ok := s.currentLambda(); ok && width <= lambda.indent {
+		s.endLambda(s.pos())
+		return
+	}
+
+	s.dedentTo(width, s.pos())
+
+	if s.indents[len(s.indents)-1] != width {
+		s.addError(s.startPos, s.pos(), "unindent doesn't match the previous indentation level")
+	}
+}
+
+// handles a line break, emitting a newline token if it ends a logical line
+func (s *Scanner) newline() {
+	if s.layoutActive() {
+		if len(s.tokens) > 0 && s.lastTokenType() != TokenNewline {
+			s.addLayoutToken(TokenNewline, s.startPos, s.pos())
+		}
+	} else if s.lastTokenType() == TokenColon && s.lambdaDepth == s.depth {
+		// a lambda's body starts on the next line so its block becomes significant
+		s.lambdas = append(s.lambdas, lambdaBlock{
+			depth:  s.depth,
+			indent: s.indents[len(s.indents)-1],
+		})
+		s.lambdaDepth = -1
+		s.addLayoutToken(TokenNewline, s.startPos, s.pos())
+	}
+
+	s.atLineStart = true
+}
+
+func (s *Scanner) openBracket(tokenType TokenType) {
+	s.depth += 1
+	s.addToken(tokenType)
+}
+
+func (s *Scanner) closeBracket(tokenType TokenType) {
+	// the bracket closes around a lambda whose block never dedented. The bracket has already been
+	// consumed, so the dedents go where it starts to keep them before it
+	if _, ok := s.currentLambda(); ok {
+		s.endLambda(s.startPos)
+	}
+
+	if s.depth > 0 {
+		s.depth -= 1
+	}
+	s.addToken(tokenType)
+}
+
+func (s *Scanner) identifier() {
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+
+	text := s.source[s.start:s.current]
+	tokenType, exists := GDScriptKeywords[text]
+	if !exists {
+		tokenType = TokenIdentifier
+	}
+
+	if tokenType == TokenFunc && s.depth > 0 {
+		s.lambdaDepth = s.depth
+	}
+
+	s.addToken(tokenType)
+}
+
+// lexes a string literal after its opening quote. The token's value is the
+// source text between the quotes and its literal is the decoded string.
+// Escape sequences are not decoded in raw strings
+func (s *Scanner) makeString(quote rune, t TokenType) {
+	delimiter := string(quote)
+	if strings.HasPrefix(s.source[s.current:], delimiter+delimiter) {
 		s.advance()
 		s.advance()
+		delimiter = strings.Repeat(delimiter, 3)
+	}
 
-		for {
-			if s.isAtEnd() {
-				return NewLexicalError(s.line, s.start, s.current, "unterminated string")
-			}
+	multiline := len(delimiter) == 3
+	contentStart := s.current
+	var decoded strings.Builder
 
-			// Check for closing triple quotes
-			if s.current+2 < len(s.source) &&
-				rune(s.source[s.current]) == c &&
-				rune(s.source[s.current+1]) == c &&
-				rune(s.source[s.current+2]) == c {
-				// Advance past the closing quotes
-				s.advance()
-				s.advance()
-				s.advance()
-				break
-			}
+	for !strings.HasPrefix(s.source[s.current:], delimiter) {
+		// the rest of the line is the unterminated string, scanning resumes on the next line
+		if s.isAtEnd() || (!multiline && s.peek() == '\n') {
+			s.addErrorToken("unterminated string")
+			return
+		}
+
+		escapeStart := s.pos()
+		c := s.advance()
 
-			if s.peek() == '\n' {
-				s.line++ // Track line numbers for multiline strings
+		switch {
+		case c != '\\':
+			decoded.WriteRune(c)
+		case t == TokenRawString:
+			// raw strings keep escapes as written, an escaped quote or
+			// backslash just doesn't end the string
+			decoded.WriteRune(c)
+			if s.peek() == quote || s.peek() == '\\' {
+				decoded.WriteRune(s.advance())
 			}
-			s.advance()
+		default:
+			s.escape(escapeStart, &decoded, multiline)
 		}
+	}
+
+	value := s.source[contentStart:s.current]
+	for range delimiter {
+		s.advance()
+	}
+
+	s.addLiteralToken(t, value, decoded.String())
+}
 
-		// Extract the value inside the triple quotes
-		value := s.source[s.start+3 : s.current-3]
-		s.addTokenWithValue(t, value)
-	} else { // Handle single-quoted strings as usual
-		for s.peek() != c && !s.isAtEnd() {
-			if s.peek() == '\n' {
-				return NewLexicalError(s.line, s.start, s.current, "unterminated string")
+// decodes the escape sequence after a backslash in a string, reporting
+// invalid escapes and writing the replacement character in their place
+func (s *Scanner) escape(start Position, decoded *strings.Builder, multiline bool) {
+	if s.isAtEnd() {
+		// reported as an unterminated string
+		return
+	}
+
+	c := s.advance()
+
+	switch c {
+	case 'n':
+		decoded.WriteByte('\n')
+	case 't':
+		decoded.WriteByte('\t')
+	case 'r':
+		decoded.WriteByte('\r')
+	case 'a':
+		decoded.WriteByte('\a')
+	case 'b':
+		decoded.WriteByte('\b')
+	case 'f':
+		decoded.WriteByte('\f')
+	case 'v':
+		decoded.WriteByte('\v')
+	case '"', '\'', '\\':
+		decoded.WriteRune(c)
+	case '\r', '\n':
+		if c == '\r' {
+			s.match('\n')
+		}
+
+		// a backslash at the end of a line joins it with the next one
+		if !multiline {
+			s.addError(start, s.pos(), "line continuation is only allowed in triple-quoted strings")
+		}
+	case 'u', 'U':
+		count := 4
+		if c == 'U' {
+			count = 6
+		}
+
+		code, ok := s.hexEscape(count)
+		if !ok {
+			s.addError(start, s.pos(), "invalid hexadecimal digit in unicode escape sequence")
+			decoded.WriteRune(utf8.RuneError)
+			return
+		}
+
+		switch {
+		case utf16.IsSurrogate(code) && code < 0xDC00:
+			// a lead surrogate has to be followed by an escaped trail surrogate
+			if trail, ok := s.trailSurrogate(); ok {
+				decoded.WriteRune(utf16.DecodeRune(code, trail))
+				return
 			}
-			s.advance()
+
+			s.addError(start, s.pos(), "invalid UTF-16 sequence in string, unpaired lead surrogate")
+			decoded.WriteRune(utf8.RuneError)
+		case utf16.IsSurrogate(code):
+			s.addError(start, s.pos(), "invalid UTF-16 sequence in string, unpaired trail surrogate")
+			decoded.WriteRune(utf8.RuneError)
+		case code > unicode.MaxRune:
+			s.addError(start, s.pos(), "invalid code point in unicode escape sequence")
+			decoded.WriteRune(utf8.RuneError)
+		default:
+			decoded.WriteRune(code)
 		}
+	default:
+		s.addError(start, s.pos(), fmt.Sprintf("invalid escape sequence '\\%c' in string", c))
+		decoded.WriteRune(utf8.RuneError)
+	}
+}
+
+// reads the given number of hex digits of a unicode escape
+func (s *Scanner) hexEscape(count int) (rune, bool) {
+	code := rune(0)
 
-		if s.isAtEnd() {
-			return NewLexicalError(s.line, s.start, s.current, "unterminated string")
+	for range count {
+		if !isHexDigit(s.peek()) {
+			return 0, false
 		}
 
-		// Advance for the closing quote
+		digit, _ := strconv.ParseUint(string(s.advance()), 16, 8)
+		code = code*16 + rune(digit)
+	}
+
+	return code, true
+}
+
+// consumes a \uXXXX trail surrogate if one comes next
+func (s *Scanner) trailSurrogate() (rune, bool) {
+	rest := s.source[s.current:]
+	if len(rest) < 6 || !strings.HasPrefix(rest, "\\u") {
+		return 0, false
+	}
+
+	code, err := strconv.ParseUint(rest[2:6], 16, 32)
+	if err != nil || code < 0xDC00 || code > 0xDFFF {
+		return 0, false
+	}
+
+	for range 6 {
 		s.advance()
+	}
+
+	return rune(code), true
+}
+
+func isDecimalDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c rune) bool {
+	return isDecimalDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isBinaryDigit(c rune) bool {
+	return c == '0' || c == '1'
+}
+
+// consumes digits and '_' separators, returning how many digits were found
+func (s *Scanner) digits(isDigit func(rune) bool) int {
+	count := 0
+
+	for isDigit(s.peek()) || s.peek() == '_' {
+		if s.advance() != '_' {
+			count++
+		}
+	}
+
+	return count
+}
+
+// lexes an integer or float literal starting with the given character, which
+// is either a digit or the '.' of a float such as .5
+func (s *Scanner) number(first rune) {
+	if first == '0' && (s.peek() == 'x' || s.peek() == 'X') {
+		s.advance()
+		s.integer(16, isHexDigit, "hexadecimal")
+		return
+	}
+
+	if first == '0' && (s.peek() == 'b' || s.peek() == 'B') {
+		s.advance()
+		s.integer(2, isBinaryDigit, "binary")
+		return
+	}
+
+	isFloat := first == '.'
+	s.digits(isDecimalDigit)
+
+	// a trailing '.' makes a float such as 1. unless it starts a range or a
+	// method call on the number
+	if !isFloat && s.peek() == '.' && s.peekNext() != '.' &&
+		(!isAlpha(s.peekNext()) || s.peekNext() == 'e' || s.peekNext() == 'E') {
+		isFloat = true
+		s.advance()
+		s.digits(isDecimalDigit)
+	}
+
+	if s.peek() == 'e' || s.peek() == 'E' {
+		isFloat = true
+		s.advance()
+
+		if s.peek() == '+' || s.peek() == '-' {
+			s.advance()
+		}
+
+		if s.digits(isDecimalDigit) == 0 {
+			s.addErrorToken("expected exponent digits in float literal")
+			return
+		}
+	}
+
+	if s.invalidDigits("decimal") {
+		return
+	}
+
+	value := strings.ReplaceAll(s.source[s.start:s.current], "_", "")
+
+	if isFloat {
+		// out of range floats become infinity as in Godot
+		number, _ := strconv.ParseFloat(value, 64)
+		s.addLiteralToken(TokenFloat, value, number)
+		return
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		s.addErrorToken("integer literal is too large")
+		return
+	}
 
-		value := s.source[s.start+1 : s.current-1]
-		s.addTokenWithValue(t, value)
+	s.addLiteralToken(TokenInt, value, number)
+}
+
+// lexes the digits of a hexadecimal or binary integer after its prefix
+func (s *Scanner) integer(base int, isDigit func(rune) bool, name string) {
+	prefix := s.source[s.start:s.current]
+
+	if s.digits(isDigit) == 0 {
+		s.addErrorToken(fmt.Sprintf("expected %s digits after '%s'", name, prefix))
+		return
+	}
+
+	if s.invalidDigits(name) {
+		return
 	}
-	return nil
+
+	value := strings.ReplaceAll(s.source[s.start:s.current], "_", "")
+
+	// Godot reads hexadecimal and binary literals as 64 bit patterns, so 0xFFFFFFFFFFFFFFFF is -1
+	number, err := strconv.ParseUint(value[2:], base, 64)
+	if err != nil {
+		s.addErrorToken(fmt.Sprintf("%s literal is too large", name))
+		return
+	}
+
+	s.addLiteralToken(TokenInt, value, int64(number))
 }
 
-func (s *Scanner) ScanTokens() ([]Token, error) {
+// reports letters or digits directly after a number, such as the 2 in 0b102,
+// consuming them so they don't become an identifier
+func (s *Scanner) invalidDigits(name string) bool {
+	if !isAlphaNumeric(s.peek()) {
+		return false
+	}
+
+	invalid := s.peek()
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+
+	s.addErrorToken(fmt.Sprintf("invalid digit '%c' in %s literal", invalid, name))
+	return true
+}
+
+// scans the whole source. Scanning doesn't stop at lexical errors, every error is
+// returned and the invalid text is kept in the token stream as TokenUnknown tokens
+func (s *Scanner) ScanTokens() ([]Token, []*LexicalError) {
 	for !s.isAtEnd() {
-		s.start = s.current
+		if s.atLineStart {
+			s.scanIndentation()
+
+			if s.isAtEnd() {
+				break
+			}
+		}
+
+		s.startToken()
 		c := s.advance()
 
 		switch c {
 		case '(':
-			s.addToken(TokenLParen)
+			s.openBracket(TokenLParen)
 		case ')':
-			s.addToken(TokenRParen)
+			s.closeBracket(TokenRParen)
 		case '[':
-			s.addToken(TokenLBracket)
+			s.openBracket(TokenLBracket)
 		case ']':
-			s.addToken(TokenRBracket)
+			s.closeBracket(TokenRBracket)
+		case '{':
+			s.openBracket(TokenLBrace)
+		case '}':
+			s.closeBracket(TokenRBrace)
+		case ':':
+			if s.match('=') {
+				s.addToken(TokenColonEquals)
+			} else {
+				s.addToken(TokenColon)
+			}
+		case ';':
+			s.addToken(TokenSemicolon)
+		case '$':
+			s.addToken(TokenDollar)
+		case '?':
+			s.addToken(TokenQuestionMark)
+		case '`':
+			s.addToken(TokenBacktick)
+		case '@':
+			if !isAlpha(s.peek()) {
+				s.addErrorToken("expected annotation name after '@'")
+				break
+			}
+
+			for isAlphaNumeric(s.peek()) {
+				s.advance()
+			}
+			s.addToken(TokenAnnotation)
 		case '.':
-			s.addToken(TokenPeriod)
+			if unicode.IsDigit(s.peek()) {
+				s.number(c)
+			} else if s.match('.') {
+				if s.match('.') {
+					s.addToken(TokenEllipsis)
+				} else {
+					s.addToken(TokenPeriodPeriod)
+				}
+			} else {
+				s.addToken(TokenPeriod)
+			}
 		case '~':
 			s.addToken(TokenTilda)
 		case '-':
 			if s.match('=') {
 				s.addToken(TokenMinusEqual)
+			} else if s.match('>') {
+				s.addToken(TokenArrow)
 			} else {
 				s.addToken(TokenDash)
 			}
@@ -372,28 +986,18 @@ func (s *Scanner) ScanTokens() ([]Token, error) {
 		case '&':
 			if s.match('&') {
 				s.addToken(TokenBooleanAnd)
-			} else if s.match('"') || s.match('\'') {
+			} else if s.match('=') {
+				s.addToken(TokenAndEqual)
+			} else if s.peek() == '"' || s.peek() == '\'' {
 				// StringName
-				quote := s.peek()
-				s.advance()
+				quote := s.advance()
 
-				// Set start to exclude the 'r' prefix and opening quote
-				s.start += 1
-
-				// Parse the raw string
-				err := s.makeString(quote, TokenRawString)
-				if err != nil {
-					return nil, err
-				}
+				s.makeString(quote, TokenStringName)
 			} else {
 				s.addToken(TokenAmpersand)
 			}
 		case '|':
 			if s.match('|') {
-				if s.peek() != '"' || s.peek() != '\'' {
-					break
-				}
-
 				s.addToken(TokenBooleanOr)
 			} else if s.match('=') {
 				s.addToken(TokenOrEqual)
@@ -427,7 +1031,12 @@ func (s *Scanner) ScanTokens() ([]Token, error) {
 				s.addToken(TokenLess)
 			}
 		case '^':
-			if s.match('=') {
+			if s.peek() == '"' || s.peek() == '\'' {
+				// NodePath
+				quote := s.advance()
+
+				s.makeString(quote, TokenNodePath)
+			} else if s.match('=') {
 				s.addToken(TokenXorEqual)
 			} else {
 				s.addToken(TokenXOR)
@@ -435,76 +1044,55 @@ func (s *Scanner) ScanTokens() ([]Token, error) {
 		case ' ', '\t', '\r':
 			break
 		case '\n':
-			s.line += 1
-			break
+			s.newline()
+		// line continuation
+		case '\\':
+			s.match('\r')
+			if !s.match('\n') {
+				s.addErrorToken("expected new line after '\\'")
+			}
 
 		// comments
 		case '#':
-			for s.peek() != '\n' {
+			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
-			break
 		// string literals
 		case '"', '\'':
-			if err := s.makeString(c, TokenString); err != nil {
-				return nil, err
-			}
+			s.makeString(c, TokenString)
 		// raw strings
 		case 'r':
 			// If the next character isn't a quote, treat 'r' as the start of an identifier
 			if s.peek() != '"' && s.peek() != '\'' {
-				continue //
+				s.identifier()
+				break
 			}
 
 			quote := s.peek()
 			s.advance()
 
-			// Set start to exclude the 'r' prefix and opening quote
-			s.start += 1
-
 			// Parse the raw string
-			err := s.makeString(quote, TokenRawString)
-			if err != nil {
-				return nil, err
-			}
+			s.makeString(quote, TokenRawString)
 
 		default:
 			if unicode.IsDigit(c) {
-				// lex a number token
-				for unicode.IsDigit(s.peek()) || s.peek() == '_' {
-					s.advance()
-				}
-
-				if s.peek() == '.' && (unicode.IsDigit(s.peekNext()) || s.peekNext() == '_') {
-					s.advance()
-
-					for unicode.IsDigit(s.peek()) || s.peek() == '_' {
-						s.advance()
-					}
-				}
-
-				value := s.source[s.start:s.current]
-				value = strings.ReplaceAll(value, "_", "")
-
-				s.addTokenWithValue(TokenNumber, value)
-			} else if unicode.IsLetter(c) {
-				for isAlphaNumeric(s.peek()) {
-					s.advance()
-				}
-
-				text := s.source[s.start:s.current]
-				tokenType, exists := GDScriptKeywords[text]
-				if !exists {
-					tokenType = TokenIdentifier
-				}
-
-				s.addToken(tokenType)
+				s.number(c)
+			} else if isAlpha(c) {
+				s.identifier()
 			} else {
-				return nil, NewLexicalError(s.line, s.start, s.current, fmt.Sprintf("unknown token '%s'", s.source[s.start:s.current]))
+				s.addErrorToken(fmt.Sprintf("unknown token '%s'", s.source[s.start:s.current]))
 			}
 		}
 
 	}
 
-	return s.tokens, nil
+	// close any blocks still open at the end of the file
+	s.lambdas = nil
+	s.dedentTo(0, s.pos())
+
+	// the end of file token holds any trivia after the last token
+	s.startToken()
+	s.addToken(TokenEOF)
+
+	return s.tokens, s.errors
 }
@@ -49,7 +49,7 @@ func TestScanTokens(t *testing.T) {
 		},
 		{
 			name:     "Unrecognized token",
-			input:    "?",
+			input:    "§",
 			expected: nil,
 			hasError: true,
 		},
@@ -57,12 +57,12 @@ func TestScanTokens(t *testing.T) {
 			name:  "Strings",
 			input: `"foobar" 'foobar' """foobar""" '''foobar''' r"foobar" r"""foobar"""`,
 			expected: []lexer.Token{
-				{Type: lexer.TokenString, Value: "foobar", Line: 1},
-				{Type: lexer.TokenString, Value: "foobar", Line: 1},
-				{Type: lexer.TokenString, Value: "foobar", Line: 1},
-				{Type: lexer.TokenString, Value: "foobar", Line: 1},
-				{Type: lexer.TokenRawString, Value: "foobar", Line: 1},
-				{Type: lexer.TokenRawString, Value: "foobar", Line: 1},
+				{Type: lexer.TokenString, Value: "foobar", Literal: "foobar", Line: 1},
+				{Type: lexer.TokenString, Value: "foobar", Literal: "foobar", Line: 1},
+				{Type: lexer.TokenString, Value: "foobar", Literal: "foobar", Line: 1},
+				{Type: lexer.TokenString, Value: "foobar", Literal: "foobar", Line: 1},
+				{Type: lexer.TokenRawString, Value: "foobar", Literal: "foobar", Line: 1},
+				{Type: lexer.TokenRawString, Value: "foobar", Literal: "foobar", Line: 1},
 			},
 		},
 		{
@@ -75,9 +75,44 @@ func TestScanTokens(t *testing.T) {
 			name:  "numbers",
 			input: `340 3.14 3_500_000`,
 			expected: []lexer.Token{
-				{Type: lexer.TokenNumber, Value: "340", Line: 1},
-				{Type: lexer.TokenNumber, Value: "3.14", Line: 1},
-				{Type: lexer.TokenNumber, Value: "3500000", Line: 1},
+				{Type: lexer.TokenInt, Value: "340", Literal: int64(340), Line: 1},
+				{Type: lexer.TokenFloat, Value: "3.14", Literal: 3.14, Line: 1},
+				{Type: lexer.TokenInt, Value: "3500000", Literal: int64(3500000), Line: 1},
+			},
+		},
+		{
+			name:  "Hexadecimal and binary numbers",
+			input: `0xFF 0x_dead_BEEF 0b1010 0B1_1 0xFFFFFFFFFFFFFFFF`,
+			expected: []lexer.Token{
+				{Type: lexer.TokenInt, Value: "0xFF", Literal: int64(255), Line: 1},
+				{Type: lexer.TokenInt, Value: "0xdeadBEEF", Literal: int64(0xdeadbeef), Line: 1},
+				{Type: lexer.TokenInt, Value: "0b1010", Literal: int64(10), Line: 1},
+				{Type: lexer.TokenInt, Value: "0B11", Literal: int64(3), Line: 1},
+				{Type: lexer.TokenInt, Value: "0xFFFFFFFFFFFFFFFF", Literal: int64(-1), Line: 1},
+			},
+		},
+		{
+			name:  "Floats",
+			input: `1e-5 2.5E+3 1. .5 1.e2 3e2`,
+			expected: []lexer.Token{
+				{Type: lexer.TokenFloat, Value: "1e-5", Literal: 1e-5, Line: 1},
+				{Type: lexer.TokenFloat, Value: "2.5E+3", Literal: 2500.0, Line: 1},
+				{Type: lexer.TokenFloat, Value: "1.", Literal: 1.0, Line: 1},
+				{Type: lexer.TokenFloat, Value: ".5", Literal: 0.5, Line: 1},
+				{Type: lexer.TokenFloat, Value: "1.e2", Literal: 100.0, Line: 1},
+				{Type: lexer.TokenFloat, Value: "3e2", Literal: 300.0, Line: 1},
+			},
+		},
+		{
+			name:  "Numbers next to periods",
+			input: `1..5 x.y`,
+			expected: []lexer.Token{
+				{Type: lexer.TokenInt, Value: "1", Literal: int64(1), Line: 1},
+				{Type: lexer.TokenPeriodPeriod, Value: "..", Line: 1},
+				{Type: lexer.TokenInt, Value: "5", Literal: int64(5), Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "x", Line: 1},
+				{Type: lexer.TokenPeriod, Value: ".", Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "y", Line: 1},
 			},
 		},
 		{
@@ -90,15 +125,122 @@ func TestScanTokens(t *testing.T) {
 				{Type: lexer.TokenIdentifier, Value: "foobar", Line: 1},
 			},
 		},
+		{
+			name:  "Identifiers with underscores",
+			input: "_ready class_name return rotation",
+			expected: []lexer.Token{
+				{Type: lexer.TokenIdentifier, Value: "_ready", Line: 1},
+				{Type: lexer.TokenClassName, Value: "class_name", Line: 1},
+				{Type: lexer.TokenReturn, Value: "return", Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "rotation", Line: 1},
+			},
+		},
+		{
+			name:  "Function signature",
+			input: "func f() -> int: a || b",
+			expected: []lexer.Token{
+				{Type: lexer.TokenFunc, Value: "func", Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "f", Line: 1},
+				{Type: lexer.TokenLParen, Value: "(", Line: 1},
+				{Type: lexer.TokenRParen, Value: ")", Line: 1},
+				{Type: lexer.TokenArrow, Value: "->", Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "int", Line: 1},
+				{Type: lexer.TokenColon, Value: ":", Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "a", Line: 1},
+				{Type: lexer.TokenBooleanOr, Value: "||", Line: 1},
+				{Type: lexer.TokenIdentifier, Value: "b", Line: 1},
+			},
+		},
+		{
+			name:  "Punctuation",
+			input: "{}: ; := $ % ? ` . .. ...",
+			expected: []lexer.Token{
+				{Type: lexer.TokenLBrace, Value: "{", Line: 1},
+				{Type: lexer.TokenRBrace, Value: "}", Line: 1},
+				{Type: lexer.TokenColon, Value: ":", Line: 1},
+				{Type: lexer.TokenSemicolon, Value: ";", Line: 1},
+				{Type: lexer.TokenColonEquals, Value: ":=", Line: 1},
+				{Type: lexer.TokenDollar, Value: "$", Line: 1},
+				{Type: lexer.TokenPercent, Value: "%", Line: 1},
+				{Type: lexer.TokenQuestionMark, Value: "?", Line: 1},
+				{Type: lexer.TokenBacktick, Value: "`", Line: 1},
+				{Type: lexer.TokenPeriod, Value: ".", Line: 1},
+				{Type: lexer.TokenPeriodPeriod, Value: "..", Line: 1},
+				{Type: lexer.TokenEllipsis, Value: "...", Line: 1},
+			},
+		},
+		{
+			name:  "Compound assignment operators",
+			input: "&= |= ^= **= <<= >>=",
+			expected: []lexer.Token{
+				{Type: lexer.TokenAndEqual, Value: "&=", Line: 1},
+				{Type: lexer.TokenOrEqual, Value: "|=", Line: 1},
+				{Type: lexer.TokenXorEqual, Value: "^=", Line: 1},
+				{Type: lexer.TokenPowerEqual, Value: "**=", Line: 1},
+				{Type: lexer.TokenLShiftEqual, Value: "<<=", Line: 1},
+				{Type: lexer.TokenRShiftEqual, Value: ">>=", Line: 1},
+			},
+		},
+		{
+			name:  "Annotations",
+			input: "@export @onready var",
+			expected: []lexer.Token{
+				{Type: lexer.TokenAnnotation, Value: "@export", Line: 1},
+				{Type: lexer.TokenAnnotation, Value: "@onready", Line: 1},
+				{Type: lexer.TokenVar, Value: "var", Line: 1},
+			},
+		},
+		{
+			name:     "Annotation without a name",
+			input:    "@ export",
+			expected: nil,
+			hasError: true,
+		},
+		{
+			name:  "StringName and NodePath",
+			input: `&"name" &'name' ^"Path/To" ^'Path'`,
+			expected: []lexer.Token{
+				{Type: lexer.TokenStringName, Value: "name", Literal: "name", Line: 1},
+				{Type: lexer.TokenStringName, Value: "name", Literal: "name", Line: 1},
+				{Type: lexer.TokenNodePath, Value: "Path/To", Literal: "Path/To", Line: 1},
+				{Type: lexer.TokenNodePath, Value: "Path", Literal: "Path", Line: 1},
+			},
+		},
+		{
+			name:  "Keyword operators and constants",
+			input: "and or not true false null",
+			expected: []lexer.Token{
+				{Type: lexer.TokenBooleanAnd, Value: "and", Line: 1},
+				{Type: lexer.TokenBooleanOr, Value: "or", Line: 1},
+				{Type: lexer.TokenBang, Value: "not", Line: 1},
+				{Type: lexer.TokenTrue, Value: "true", Line: 1},
+				{Type: lexer.TokenFalse, Value: "false", Line: 1},
+				{Type: lexer.TokenNull, Value: "null", Line: 1},
+			},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			scanner := lexer.NewScanner(test.input)
-			tokens, err := scanner.ScanTokens()
+			tokens, errs := scanner.ScanTokens()
 
-			if (err != nil) != test.hasError {
-				t.Fatalf("expected error: %v, got: %v", test.hasError, err)
+			if (len(errs) > 0) != test.hasError {
+				t.Fatalf("expected error: %v, got: %v", test.hasError, errs)
+			}
+
+			if last := tokens[len(tokens)-1]; last.Type != lexer.TokenEOF {
+				t.Fatalf("expected the last token to be EOF, got: %v", last)
+			}
+			tokens = tokens[:len(tokens)-1]
+
+			// positions and source text are covered by TestScanPositions and TestScanTrivia
+			for i := range tokens {
+				tokens[i].Start = lexer.Position{}
+				tokens[i].End = lexer.Position{}
+				tokens[i].Lexeme = ""
+				tokens[i].Leading = nil
+				tokens[i].Trailing = nil
 			}
 
 			if !test.hasError && !reflect.DeepEqual(tokens, test.expected) {
@@ -107,3 +249,467 @@ func TestScanTokens(t *testing.T) {
 		})
 	}
 }
+
+func TestScanLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []lexer.TokenType
+	}{
+		{
+			name:  "Block",
+			input: "if a:\n\tb\nc",
+			expected: []lexer.TokenType{
+				lexer.TokenIf, lexer.TokenIdentifier, lexer.TokenColon, lexer.TokenNewline,
+				lexer.TokenIndent, lexer.TokenIdentifier, lexer.TokenNewline,
+				lexer.TokenDedent, lexer.TokenIdentifier,
+			},
+		},
+		{
+			name:  "Nested blocks closed at end of file",
+			input: "if a:\n    if b:\n        c\n",
+			expected: []lexer.TokenType{
+				lexer.TokenIf, lexer.TokenIdentifier, lexer.TokenColon, lexer.TokenNewline,
+				lexer.TokenIndent, lexer.TokenIf, lexer.TokenIdentifier, lexer.TokenColon, lexer.TokenNewline,
+				lexer.TokenIndent, lexer.TokenIdentifier, lexer.TokenNewline,
+				lexer.TokenDedent, lexer.TokenDedent,
+			},
+		},
+		{
+			name:  "Blank and comment lines are ignored",
+			input: "a\n\n\t# comment\n  \nb\n",
+			expected: []lexer.TokenType{
+				lexer.TokenIdentifier, lexer.TokenNewline, lexer.TokenIdentifier, lexer.TokenNewline,
+			},
+		},
+		{
+			name:  "Brackets suppress layout",
+			input: "a = [\n\t1,\n\t\t2\n]\n",
+			expected: []lexer.TokenType{
+				lexer.TokenIdentifier, lexer.TokenEquals, lexer.TokenLBracket, lexer.TokenInt, lexer.TokenComma,
+				lexer.TokenInt, lexer.TokenRBracket, lexer.TokenNewline,
+			},
+		},
+		{
+			name:  "Line continuation",
+			input: "a = 1 + \\\n\t\t2\nb",
+			expected: []lexer.TokenType{
+				lexer.TokenIdentifier, lexer.TokenEquals, lexer.TokenInt, lexer.TokenPlus,
+				lexer.TokenInt, lexer.TokenNewline, lexer.TokenIdentifier,
+			},
+		},
+		{
+			name:  "Lambda block inside brackets",
+			input: "f(func():\n\ta\n\tb\n)\n",
+			expected: []lexer.TokenType{
+				lexer.TokenIdentifier, lexer.TokenLParen, lexer.TokenFunc, lexer.TokenLParen, lexer.TokenRParen,
+				lexer.TokenColon, lexer.TokenNewline, lexer.TokenIndent, lexer.TokenIdentifier, lexer.TokenNewline,
+				lexer.TokenIdentifier, lexer.TokenNewline, lexer.TokenDedent, lexer.TokenRParen, lexer.TokenNewline,
+			},
+		},
+		{
+			name:  "Lambda block closed by a bracket on its last line",
+			input: "btn.pressed.connect(func():\n\tprint(1))\n",
+			expected: []lexer.TokenType{
+				lexer.TokenIdentifier, lexer.TokenPeriod, lexer.TokenIdentifier, lexer.TokenPeriod, lexer.TokenIdentifier,
+				lexer.TokenLParen, lexer.TokenFunc, lexer.TokenLParen, lexer.TokenRParen, lexer.TokenColon, lexer.TokenNewline,
+				lexer.TokenIndent, lexer.TokenIdentifier, lexer.TokenLParen, lexer.TokenInt, lexer.TokenRParen,
+				lexer.TokenDedent, lexer.TokenRParen, lexer.TokenNewline,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, errs := lexer.NewScanner(test.input).ScanTokens()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			actual := make([]lexer.TokenType, 0)
+			for _, token := range tokens {
+				actual = append(actual, token.Type)
+			}
+
+			expected := append(test.expected, lexer.TokenEOF)
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected token types: %v, got: %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestScanIndentationErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected lexer.LexicalError
+	}{
+		{
+			name:  "Mixed tabs and spaces",
+			input: "if a:\n \tb",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 2, Column: 0, Offset: 6},
+				End:     lexer.Position{Line: 2, Column: 2, Offset: 8},
+				Message: "mixed use of tabs and spaces for indentation",
+			},
+		},
+		{
+			name:  "Tabs after spaces",
+			input: "if a:\n    b\nif c:\n\td",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 4, Column: 0, Offset: 18},
+				End:     lexer.Position{Line: 4, Column: 1, Offset: 19},
+				Message: "used tab character for indentation instead of space as used before in the file",
+			},
+		},
+		{
+			name:  "Inconsistent dedent",
+			input: "if a:\n    if b:\n        c\n  d",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 4, Column: 0, Offset: 26},
+				End:     lexer.Position{Line: 4, Column: 2, Offset: 28},
+				Message: "unindent doesn't match the previous indentation level",
+			},
+		},
+		{
+			name:  "Backslash without new line",
+			input: "a \\ b",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 2, Offset: 2},
+				End:     lexer.Position{Line: 1, Column: 3, Offset: 3},
+				Message: "expected new line after '\\'",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, errs := lexer.NewScanner(test.input).ScanTokens()
+			if len(errs) != 1 {
+				t.Fatalf("expected one error, got: %v", errs)
+			}
+
+			if *errs[0] != test.expected {
+				t.Errorf("expected error: %+v, got: %+v", test.expected, *errs[0])
+			}
+		})
+	}
+}
+
+func TestScanPositions(t *testing.T) {
+	input := "var größe = \"日本\"\n\tx -> 😀"
+
+	expected := []lexer.Token{
+		{Type: lexer.TokenVar, Value: "var", Lexeme: "var", Line: 1, Start: lexer.Position{Line: 1, Column: 0, Offset: 0}, End: lexer.Position{Line: 1, Column: 3, Offset: 3}},
+		{Type: lexer.TokenIdentifier, Value: "größe", Lexeme: "größe", Line: 1, Start: lexer.Position{Line: 1, Column: 4, Offset: 4}, End: lexer.Position{Line: 1, Column: 11, Offset: 11}},
+		{Type: lexer.TokenEquals, Value: "=", Lexeme: "=", Line: 1, Start: lexer.Position{Line: 1, Column: 12, Offset: 12}, End: lexer.Position{Line: 1, Column: 13, Offset: 13}},
+		{Type: lexer.TokenString, Value: "日本", Literal: "日本", Lexeme: "\"日本\"", Line: 1, Start: lexer.Position{Line: 1, Column: 14, Offset: 14}, End: lexer.Position{Line: 1, Column: 22, Offset: 22}},
+		{Type: lexer.TokenNewline, Lexeme: "\n", Line: 1, Start: lexer.Position{Line: 1, Column: 22, Offset: 22}, End: lexer.Position{Line: 2, Column: 0, Offset: 23}},
+		{Type: lexer.TokenIndent, Lexeme: "\t", Line: 2, Start: lexer.Position{Line: 2, Column: 0, Offset: 23}, End: lexer.Position{Line: 2, Column: 1, Offset: 24}},
+		{Type: lexer.TokenIdentifier, Value: "x", Lexeme: "x", Line: 2, Start: lexer.Position{Line: 2, Column: 1, Offset: 24}, End: lexer.Position{Line: 2, Column: 2, Offset: 25}},
+		{Type: lexer.TokenArrow, Value: "->", Lexeme: "->", Line: 2, Start: lexer.Position{Line: 2, Column: 3, Offset: 26}, End: lexer.Position{Line: 2, Column: 5, Offset: 28}},
+		{Type: lexer.TokenUnknown, Value: "😀", Lexeme: "😀", Line: 2, Start: lexer.Position{Line: 2, Column: 6, Offset: 29}, End: lexer.Position{Line: 2, Column: 10, Offset: 33}},
+		{Type: lexer.TokenDedent, Line: 2, Start: lexer.Position{Line: 2, Column: 10, Offset: 33}, End: lexer.Position{Line: 2, Column: 10, Offset: 33}},
+		{Type: lexer.TokenEOF, Line: 2, Start: lexer.Position{Line: 2, Column: 10, Offset: 33}, End: lexer.Position{Line: 2, Column: 10, Offset: 33}},
+	}
+
+	tokens, errs := lexer.NewScanner(input).ScanTokens()
+
+	expectedErr := lexer.LexicalError{
+		Start:   lexer.Position{Line: 2, Column: 6, Offset: 29},
+		End:     lexer.Position{Line: 2, Column: 10, Offset: 33},
+		Message: "unknown token '😀'",
+	}
+
+	if len(errs) != 1 || *errs[0] != expectedErr {
+		t.Fatalf("expected error: %+v, got: %v", expectedErr, errs)
+	}
+
+	// trivia is covered by TestScanTrivia
+	for i := range tokens {
+		tokens[i].Leading = nil
+		tokens[i].Trailing = nil
+	}
+
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected tokens: %+v, got: %+v", expected, tokens)
+	}
+}
+
+func TestScanNumberErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected lexer.LexicalError
+	}{
+		{
+			input: "0x",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 0, Offset: 0},
+				End:     lexer.Position{Line: 1, Column: 2, Offset: 2},
+				Message: "expected hexadecimal digits after '0x'",
+			},
+		},
+		{
+			input: "a = 0b_",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 4, Offset: 4},
+				End:     lexer.Position{Line: 1, Column: 7, Offset: 7},
+				Message: "expected binary digits after '0b'",
+			},
+		},
+		{
+			input: "1e",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 0, Offset: 0},
+				End:     lexer.Position{Line: 1, Column: 2, Offset: 2},
+				Message: "expected exponent digits in float literal",
+			},
+		},
+		{
+			input: "2.5e-",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 0, Offset: 0},
+				End:     lexer.Position{Line: 1, Column: 5, Offset: 5},
+				Message: "expected exponent digits in float literal",
+			},
+		},
+		{
+			input: "0b102",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 0, Offset: 0},
+				End:     lexer.Position{Line: 1, Column: 5, Offset: 5},
+				Message: "invalid digit '2' in binary literal",
+			},
+		},
+		{
+			input: "12abc",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 0, Offset: 0},
+				End:     lexer.Position{Line: 1, Column: 5, Offset: 5},
+				Message: "invalid digit 'a' in decimal literal",
+			},
+		},
+		{
+			input: "99999999999999999999",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 0, Offset: 0},
+				End:     lexer.Position{Line: 1, Column: 20, Offset: 20},
+				Message: "integer literal is too large",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			tokens, errs := lexer.NewScanner(test.input).ScanTokens()
+
+			if len(errs) != 1 || *errs[0] != test.expected {
+				t.Fatalf("expected error: %+v, got: %v", test.expected, errs)
+			}
+
+			// the malformed literal is kept as a single unknown token
+			last := tokens[len(tokens)-2]
+			if last.Type != lexer.TokenUnknown || last.End != test.expected.End {
+				t.Errorf("expected an unknown token ending at %+v, got: %+v", test.expected.End, last)
+			}
+		})
+	}
+}
+
+func TestScanStringEscapes(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		value    string
+		expected string
+	}{
+		{name: "Escaped quote", input: `"a\"b"`, value: `a\"b`, expected: `a"b`},
+		{name: "Control characters", input: `'\n\t\r\\'`, value: `\n\t\r\\`, expected: "\n\t\r\\"},
+		{name: "Unicode escapes", input: `"\u00e9\U01F600"`, value: `\u00e9\U01F600`, expected: "é😀"},
+		{name: "Surrogate pair", input: `"\uD83D\uDE00"`, value: `\uD83D\uDE00`, expected: "😀"},
+		{name: "Multiline continuation", input: "\"\"\"a\\\nb\"\"\"", value: "a\\\nb", expected: "ab"},
+		{name: "Raw string", input: `r"a\nb\"c"`, value: `a\nb\"c`, expected: `a\nb\"c`},
+		{name: "StringName", input: `&"a\tb"`, value: `a\tb`, expected: "a\tb"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens, errs := lexer.NewScanner(test.input).ScanTokens()
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			if len(tokens) != 2 || tokens[0].Value != test.value || tokens[0].Literal != test.expected {
+				t.Errorf("expected value %q decoded as %q, got: %+v", test.value, test.expected, tokens)
+			}
+		})
+	}
+}
+
+func TestScanStringEscapeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected lexer.LexicalError
+	}{
+		{
+			input: `"a\qb"`,
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 2, Offset: 2},
+				End:     lexer.Position{Line: 1, Column: 4, Offset: 4},
+				Message: "invalid escape sequence '\\q' in string",
+			},
+		},
+		{
+			input: `"\u12G4"`,
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 1, Offset: 1},
+				End:     lexer.Position{Line: 1, Column: 5, Offset: 5},
+				Message: "invalid hexadecimal digit in unicode escape sequence",
+			},
+		},
+		{
+			input: `"\uD83Dx"`,
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 1, Offset: 1},
+				End:     lexer.Position{Line: 1, Column: 7, Offset: 7},
+				Message: "invalid UTF-16 sequence in string, unpaired lead surrogate",
+			},
+		},
+		{
+			input: `"\uDE00"`,
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 1, Offset: 1},
+				End:     lexer.Position{Line: 1, Column: 7, Offset: 7},
+				Message: "invalid UTF-16 sequence in string, unpaired trail surrogate",
+			},
+		},
+		{
+			input: "\"a\\\nb\"",
+			expected: lexer.LexicalError{
+				Start:   lexer.Position{Line: 1, Column: 2, Offset: 2},
+				End:     lexer.Position{Line: 2, Column: 0, Offset: 4},
+				Message: "line continuation is only allowed in triple-quoted strings",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			tokens, errs := lexer.NewScanner(test.input).ScanTokens()
+
+			if len(errs) != 1 || *errs[0] != test.expected {
+				t.Fatalf("expected error: %+v, got: %v", test.expected, errs)
+			}
+
+			// the string itself is still scanned
+			if len(tokens) != 2 || tokens[0].Type != lexer.TokenString {
+				t.Errorf("expected a single string token, got: %+v", tokens)
+			}
+		})
+	}
+}
+
+func TestScanRecoversFromErrors(t *testing.T) {
+	input := "a § b\nc = \"unterminated\nd ¤ e"
+
+	tokens, errs := lexer.NewScanner(input).ScanTokens()
+
+	expectedMessages := []string{
+		"unknown token '§'",
+		"unterminated string",
+		"unknown token '¤'",
+	}
+
+	actualMessages := make([]string, 0)
+	for _, err := range errs {
+		actualMessages = append(actualMessages, err.Message)
+	}
+
+	if !reflect.DeepEqual(actualMessages, expectedMessages) {
+		t.Fatalf("expected errors: %v, got: %v", expectedMessages, actualMessages)
+	}
+
+	expectedTypes := []lexer.TokenType{
+		lexer.TokenIdentifier, lexer.TokenUnknown, lexer.TokenIdentifier, lexer.TokenNewline,
+		lexer.TokenIdentifier, lexer.TokenEquals, lexer.TokenUnknown, lexer.TokenNewline,
+		lexer.TokenIdentifier, lexer.TokenUnknown, lexer.TokenIdentifier, lexer.TokenEOF,
+	}
+
+	actualTypes := make([]lexer.TokenType, 0)
+	for _, token := range tokens {
+		actualTypes = append(actualTypes, token.Type)
+	}
+
+	if !reflect.DeepEqual(actualTypes, expectedTypes) {
+		t.Errorf("expected token types: %v, got: %v", expectedTypes, actualTypes)
+	}
+
+	if tokens[6].Value != "\"unterminated" {
+		t.Errorf("expected the unterminated string to be kept as an unknown token, got %q", tokens[6].Value)
+	}
+}
+
+func TestScanTrivia(t *testing.T) {
+	input := "## A player.\n#region Stats\nvar speed = 1 # in m/s\n\nfunc f(\n\ta,  # first\n\tb\n):\n\t\tpass \\\n\t\t\t# done\n#endregion\n# trailing comment"
+
+	tokens, errs := lexer.NewScanner(input).ScanTokens()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	if output := lexer.Render(tokens); output != input {
+		t.Fatalf("expected source to round trip, got: %q", output)
+	}
+
+	first := tokens[0]
+	expectedLeading := []lexer.Trivia{
+		{Type: lexer.TriviaDocComment, Value: "## A player.", Start: lexer.Position{Line: 1, Column: 0, Offset: 0}, End: lexer.Position{Line: 1, Column: 12, Offset: 12}},
+		{Type: lexer.TriviaNewline, Value: "\n", Start: lexer.Position{Line: 1, Column: 12, Offset: 12}, End: lexer.Position{Line: 2, Column: 0, Offset: 13}},
+		{Type: lexer.TriviaRegion, Value: "#region Stats", Start: lexer.Position{Line: 2, Column: 0, Offset: 13}, End: lexer.Position{Line: 2, Column: 13, Offset: 26}},
+		{Type: lexer.TriviaNewline, Value: "\n", Start: lexer.Position{Line: 2, Column: 13, Offset: 26}, End: lexer.Position{Line: 3, Column: 0, Offset: 27}},
+	}
+
+	if first.Type != lexer.TokenVar || !reflect.DeepEqual(first.Leading, expectedLeading) {
+		t.Errorf("expected leading trivia: %+v, got: %+v", expectedLeading, first)
+	}
+
+	// a comment at the end of a line trails the token before it
+	one := tokens[3]
+	expectedTrailing := []lexer.Trivia{
+		{Type: lexer.TriviaWhitespace, Value: " ", Start: lexer.Position{Line: 3, Column: 13, Offset: 40}, End: lexer.Position{Line: 3, Column: 14, Offset: 41}},
+		{Type: lexer.TriviaComment, Value: "# in m/s", Start: lexer.Position{Line: 3, Column: 14, Offset: 41}, End: lexer.Position{Line: 3, Column: 22, Offset: 49}},
+	}
+
+	if one.Value != "1" || !reflect.DeepEqual(one.Trailing, expectedTrailing) {
+		t.Errorf("expected trailing trivia: %+v, got: %+v", expectedTrailing, one)
+	}
+
+	all := make([]lexer.Trivia, 0)
+	for _, token := range tokens {
+		all = append(all, token.Leading...)
+		all = append(all, token.Trailing...)
+	}
+
+	types := make(map[lexer.TriviaType]bool)
+	for _, trivia := range all {
+		types[trivia.Type] = true
+	}
+
+	if !types[lexer.TriviaLineContinuation] || !types[lexer.TriviaEndRegion] {
+		t.Errorf("expected line continuation and #endregion trivia, got: %v", types)
+	}
+
+	// the dedent closing a lambda goes before the bracket that closes it, so no trivia is lost
+	for _, lambda := range []string{"f(func():\n\tpass)", "f(func():\n\tpass )  # done\n"} {
+		tokens, _ := lexer.NewScanner(lambda).ScanTokens()
+		if output := lexer.Render(tokens); output != lambda {
+			t.Errorf("expected %q to round trip, got: %q", lambda, output)
+		}
+	}
+
+	// the comment at the end of the file has no newline after it
+	if last := all[len(all)-1]; last.Type != lexer.TriviaComment || last.Value != "# trailing comment" {
+		t.Errorf("expected the file to end with a comment, got: %+v", last)
+	}
+}
@@ -0,0 +1,112 @@
+package lexer
+
+import "strings"
+
+type TriviaType = int
+
+const (
+	// spaces and tabs, including indentation that isn't part of an indent token
+	TriviaWhitespace TriviaType = iota
+	// a line break that isn't a newline token, such as one inside brackets or after a blank line
+	TriviaNewline
+	// a backslash that joins a line with the next one
+	TriviaLineContinuation
+	TriviaComment
+	// a comment starting with ## documenting the declaration after it
+	TriviaDocComment
+	// the #region and #endregion markers used for code folding
+	TriviaRegion
+	TriviaEndRegion
+)
+
+// source text between tokens that doesn't affect parsing
+type Trivia struct {
+	Type  TriviaType
+	Value string
+	Start Position
+	End   Position
+}
+
+// splits the text between two tokens into trivia, start is the position of the text in the source
+func splitTrivia(text string, start Position) []Trivia {
+	trivia := make([]Trivia, 0)
+	pos := start
+
+	for i := 0; i < len(text); {
+		triviaType := TriviaWhitespace
+		length := 0
+
+		switch {
+		case text[i] == '\n':
+			triviaType = TriviaNewline
+			length = 1
+		case strings.HasPrefix(text[i:], "\r\n"):
+			triviaType = TriviaNewline
+			length = 2
+		case text[i] == '\\':
+			triviaType = TriviaLineContinuation
+			length = 1
+		case text[i] == '#':
+			length = strings.IndexAny(text[i:], "\r\n")
+			if length == -1 {
+				length = len(text) - i
+			}
+
+			comment := text[i : i+length]
+			switch {
+			case strings.HasPrefix(comment, "##"):
+				triviaType = TriviaDocComment
+			case strings.HasPrefix(comment, "#region"):
+				triviaType = TriviaRegion
+			case strings.HasPrefix(comment, "#endregion"):
+				triviaType = TriviaEndRegion
+			default:
+				triviaType = TriviaComment
+			}
+		default:
+			for i+length < len(text) && strings.IndexByte(" \t\r\f\v", text[i+length]) != -1 &&
+				!strings.HasPrefix(text[i+length:], "\r\n") {
+				length++
+			}
+
+			// anything else between tokens is kept so no text is lost
+			if length == 0 {
+				length = 1
+			}
+		}
+
+		end := pos
+		end.Offset += length
+		if triviaType == TriviaNewline {
+			end.Line += 1
+			end.Column = 0
+		} else {
+			end.Column += length
+		}
+
+		trivia = append(trivia, Trivia{Type: triviaType, Value: text[i : i+length], Start: pos, End: end})
+		pos = end
+		i += length
+	}
+
+	return trivia
+}
+
+// rebuilds the source text a token stream was scanned from
+func Render(tokens []Token) string {
+	var source strings.Builder
+
+	for _, token := range tokens {
+		for _, trivia := range token.Leading {
+			source.WriteString(trivia.Value)
+		}
+
+		source.WriteString(token.Lexeme)
+
+		for _, trivia := range token.Trailing {
+			source.WriteString(trivia.Value)
+		}
+	}
+
+	return source.String()
+}
@@ -0,0 +1,422 @@
+package parser
+
+import "gdx/analysis/lexer"
+
+type Position = lexer.Position
+
+// the span of source code a node was parsed from
+type Range struct {
+	Start Position
+	End   Position
+}
+
+type Node interface {
+	Span() Range
+}
+
+// a class level declaration or standalone annotation
+type Member interface {
+	Node
+	memberNode()
+}
+
+type Statement interface {
+	Node
+	statementNode()
+}
+
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+// a pattern in a match branch
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+// the range a node covers, embedded by every node
+type node struct {
+	Range Range
+}
+
+func (n *node) Span() Range {
+	return n.Range
+}
+
+// Declarations
+
+// a whole script file, which is itself an unnamed class
+type File struct {
+	node
+	Annotations []*Annotation
+	ClassName   *Identifier
+	Extends     *ExtendsClause
+	Members     []Member
+}
+
+type Annotation struct {
+	node
+	Name      *Identifier
+	Arguments []Expression
+}
+
+// extends Node, extends "res://base.gd" or extends "res://base.gd".Inner
+type ExtendsClause struct {
+	node
+	Path  *Literal
+	Names []*Identifier
+}
+
+type ClassDecl struct {
+	node
+	Annotations []*Annotation
+	Name        *Identifier
+	Extends     *ExtendsClause
+	Members     []Member
+}
+
+type VarDecl struct {
+	node
+	Annotations []*Annotation
+	Static      bool
+	Name        *Identifier
+	Type        *TypeExpr
+	Inferred    bool
+	Value       Expression
+	Setter      *Accessor
+	Getter      *Accessor
+}
+
+// a property setter or getter, either inline (set = set_value) or with a body
+type Accessor struct {
+	node
+	Function  *Identifier
+	Parameter *Identifier
+	Body      *Block
+}
+
+type ConstDecl struct {
+	node
+	Annotations []*Annotation
+	Name        *Identifier
+	Type        *TypeExpr
+	Inferred    bool
+	Value       Expression
+}
+
+type SignalDecl struct {
+	node
+	Annotations []*Annotation
+	Name        *Identifier
+	Parameters  []*Parameter
+}
+
+type EnumDecl struct {
+	node
+	Annotations []*Annotation
+	Name        *Identifier
+	Values      []*EnumValue
+}
+
+type EnumValue struct {
+	node
+	Name  *Identifier
+	Value Expression
+}
+
+type FuncDecl struct {
+	node
+	Annotations []*Annotation
+	Static      bool
+	Name        *Identifier
+	Parameters  []*Parameter
+	ReturnType  *TypeExpr
+	Body        *Block
+}
+
+type Parameter struct {
+	node
+	Name     *Identifier
+	Type     *TypeExpr
+	Inferred bool
+	Default  Expression
+}
+
+// a type hint such as int, Node2D.Mode, Array[int] or Dictionary[String, int]
+type TypeExpr struct {
+	node
+	Names    []*Identifier
+	Elements []*TypeExpr
+}
+
+// Statements
+
+type Block struct {
+	node
+	Statements []Statement
+}
+
+type ExpressionStmt struct {
+	node
+	Expression Expression
+}
+
+type AssignStmt struct {
+	node
+	Target   Expression
+	Operator lexer.TokenType
+	Value    Expression
+}
+
+type IfStmt struct {
+	node
+	Condition Expression
+	Body      *Block
+	// either another *IfStmt for an elif branch or a *Block for an else branch
+	Else Statement
+}
+
+type ForStmt struct {
+	node
+	Variable     *Identifier
+	VariableType *TypeExpr
+	Iterable     Expression
+	Body         *Block
+}
+
+type WhileStmt struct {
+	node
+	Condition Expression
+	Body      *Block
+}
+
+type MatchStmt struct {
+	node
+	Subject  Expression
+	Branches []*MatchBranch
+}
+
+type MatchBranch struct {
+	node
+	Patterns []Pattern
+	Guard    Expression
+	Body     *Block
+}
+
+type ReturnStmt struct {
+	node
+	Value Expression
+}
+
+type AssertStmt struct {
+	node
+	Condition Expression
+	Message   Expression
+}
+
+type PassStmt struct{ node }
+type BreakStmt struct{ node }
+type ContinueStmt struct{ node }
+type BreakpointStmt struct{ node }
+
+// Patterns
+
+type ExpressionPattern struct {
+	node
+	Expression Expression
+}
+
+// var name
+type BindingPattern struct {
+	node
+	Name *Identifier
+}
+
+// _
+type WildcardPattern struct{ node }
+
+// ..
+type RestPattern struct{ node }
+
+type ArrayPattern struct {
+	node
+	Elements []Pattern
+}
+
+type DictionaryPattern struct {
+	node
+	Entries []*DictionaryPatternEntry
+}
+
+type DictionaryPatternEntry struct {
+	node
+	// nil when the entry is a rest pattern
+	Key   Expression
+	Value Pattern
+}
+
+// Expressions
+
+type Identifier struct {
+	node
+	Name string
+}
+
+// a literal value, the kind is the token type it was scanned as and the
+// constant is the value the lexer parsed for it, if any
+type Literal struct {
+	node
+	Kind     lexer.TokenType
+	Value    string
+	Constant any
+}
+
+type SelfExpr struct{ node }
+type SuperExpr struct{ node }
+
+type ArrayLiteral struct {
+	node
+	Elements []Expression
+}
+
+type DictionaryLiteral struct {
+	node
+	Entries []*DictionaryEntry
+}
+
+type DictionaryEntry struct {
+	node
+	Key   Expression
+	Value Expression
+}
+
+type UnaryExpr struct {
+	node
+	Operator lexer.TokenType
+	Operand  Expression
+}
+
+type BinaryExpr struct {
+	node
+	Operator lexer.TokenType
+	Left     Expression
+	Right    Expression
+}
+
+// value if condition else other
+type TernaryExpr struct {
+	node
+	Condition Expression
+	True      Expression
+	False     Expression
+}
+
+// value as Type
+type CastExpr struct {
+	node
+	Value Expression
+	Type  *TypeExpr
+}
+
+// value is Type
+type TypeTestExpr struct {
+	node
+	Value   Expression
+	Type    *TypeExpr
+	Negated bool // 'is not'
+}
+
+type CallExpr struct {
+	node
+	Callee    Expression
+	Arguments []Expression
+}
+
+type AttributeExpr struct {
+	node
+	Target Expression
+	Name   *Identifier
+}
+
+type SubscriptExpr struct {
+	node
+	Target Expression
+	Index  Expression
+}
+
+type AwaitExpr struct {
+	node
+	Value Expression
+}
+
+type PreloadExpr struct {
+	node
+	Path Expression
+}
+
+// a node path shorthand such as $Path/To/Node, $"Path" or %UniqueName
+type GetNodeExpr struct {
+	node
+	Path   string
+	Unique bool
+}
+
+type LambdaExpr struct {
+	node
+	Name       *Identifier
+	Parameters []*Parameter
+	ReturnType *TypeExpr
+	Body       *Block
+}
+
+func (*Annotation) memberNode() {}
+func (*ClassDecl) memberNode()  {}
+func (*VarDecl) memberNode()    {}
+func (*ConstDecl) memberNode()  {}
+func (*SignalDecl) memberNode() {}
+func (*EnumDecl) memberNode()   {}
+func (*FuncDecl) memberNode()   {}
+
+func (*VarDecl) statementNode()        {}
+func (*ConstDecl) statementNode()      {}
+func (*Block) statementNode()          {}
+func (*ExpressionStmt) statementNode() {}
+func (*AssignStmt) statementNode()     {}
+func (*IfStmt) statementNode()         {}
+func (*ForStmt) statementNode()        {}
+func (*WhileStmt) statementNode()      {}
+func (*MatchStmt) statementNode()      {}
+func (*ReturnStmt) statementNode()     {}
+func (*AssertStmt) statementNode()     {}
+func (*PassStmt) statementNode()       {}
+func (*BreakStmt) statementNode()      {}
+func (*ContinueStmt) statementNode()   {}
+func (*BreakpointStmt) statementNode() {}
+
+func (*ExpressionPattern) patternNode() {}
+func (*BindingPattern) patternNode()    {}
+func (*WildcardPattern) patternNode()   {}
+func (*RestPattern) patternNode()       {}
+func (*ArrayPattern) patternNode()      {}
+func (*DictionaryPattern) patternNode() {}
+
+func (*Identifier) expressionNode()        {}
+func (*Literal) expressionNode()           {}
+func (*SelfExpr) expressionNode()          {}
+func (*SuperExpr) expressionNode()         {}
+func (*ArrayLiteral) expressionNode()      {}
+func (*DictionaryLiteral) expressionNode() {}
+func (*UnaryExpr) expressionNode()         {}
+func (*BinaryExpr) expressionNode()        {}
+func (*TernaryExpr) expressionNode()       {}
+func (*CastExpr) expressionNode()          {}
+func (*TypeTestExpr) expressionNode()      {}
+func (*CallExpr) expressionNode()          {}
+func (*AttributeExpr) expressionNode()     {}
+func (*SubscriptExpr) expressionNode()     {}
+func (*AwaitExpr) expressionNode()         {}
+func (*PreloadExpr) expressionNode()       {}
+func (*GetNodeExpr) expressionNode()       {}
+func (*LambdaExpr) expressionNode()        {}
@@ -0,0 +1,326 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"gdx/analysis/lexer"
+)
+
+// operator precedences from lowest to highest, matching Godot's parser
+const (
+	precNone int = iota
+	precCast
+	precTernary
+	precLogicOr
+	precLogicAnd
+	precLogicNot
+	precContentTest
+	precComparison
+	precBitOr
+	precBitXor
+	precBitAnd
+	precBitShift
+	precAddition
+	precFactor
+	precSign
+	precBitNot
+	precPower
+	precTypeTest
+	precAwait
+	precCall
+)
+
+var infixPrecedence = map[lexer.TokenType]int{
+	lexer.TokenAs:             precCast,
+	lexer.TokenIf:             precTernary,
+	lexer.TokenBooleanOr:      precLogicOr,
+	lexer.TokenBooleanAnd:     precLogicAnd,
+	lexer.TokenIn:             precContentTest,
+	lexer.TokenEqualsEquals:   precComparison,
+	lexer.TokenNotEqual:       precComparison,
+	lexer.TokenLess:           precComparison,
+	lexer.TokenLessOrEqual:    precComparison,
+	lexer.TokenGreater:        precComparison,
+	lexer.TokenGreaterOrEqual: precComparison,
+	lexer.TokenOr:             precBitOr,
+	lexer.TokenXOR:            precBitXor,
+	lexer.TokenAmpersand:      precBitAnd,
+	lexer.TokenShiftLeft:      precBitShift,
+	lexer.TokenShiftRight:     precBitShift,
+	lexer.TokenPlus:           precAddition,
+	lexer.TokenDash:           precAddition,
+	lexer.TokenStar:           precFactor,
+	lexer.TokenSlash:          precFactor,
+	lexer.TokenPercent:        precFactor,
+	lexer.TokenPower:          precPower,
+	lexer.TokenIs:             precTypeTest,
+	lexer.TokenLParen:         precCall,
+	lexer.TokenPeriod:         precCall,
+	lexer.TokenLBracket:       precCall,
+}
+
+func (p *Parser) parseExpression() Expression {
+	return p.parsePrecedence(precCast)
+}
+
+// parses an expression containing only operators that bind at least as tightly as the given precedence
+func (p *Parser) parsePrecedence(precedence int) Expression {
+	left := p.parsePrefix()
+
+	for {
+		// 'not in' is the only infix operator made of two tokens
+		if p.check(lexer.TokenBang) && p.peekAt(1).Type == lexer.TokenIn && precContentTest >= precedence {
+			operator := p.advance()
+			p.advance()
+			right := p.parsePrecedence(precContentTest + 1)
+
+			left = &UnaryExpr{
+				node:     p.nodeFromPosition(left.Span().Start),
+				Operator: operator.Type,
+				Operand: &BinaryExpr{
+					node:     p.nodeFromPosition(left.Span().Start),
+					Operator: lexer.TokenIn,
+					Left:     left,
+					Right:    right,
+				},
+			}
+			continue
+		}
+
+		operatorPrecedence, ok := infixPrecedence[p.peek().Type]
+		if !ok || operatorPrecedence < precedence {
+			return left
+		}
+
+		left = p.parseInfix(left, operatorPrecedence)
+	}
+}
+
+func (p *Parser) parsePrefix() Expression {
+	switch p.peek().Type {
+	case lexer.TokenYield:
+		p.errorAt(p.peek(), "'yield' was removed in Godot 4, use 'await' instead")
+		panic(bailout{})
+	case lexer.TokenEOF, lexer.TokenNewline, lexer.TokenIndent, lexer.TokenDedent:
+		p.fail("expected an expression")
+	}
+
+	token := p.advance()
+
+	switch token.Type {
+	case lexer.TokenIdentifier:
+		return &Identifier{node: p.nodeFrom(token), Name: token.Value}
+	case lexer.TokenInt, lexer.TokenFloat, lexer.TokenString, lexer.TokenRawString, lexer.TokenStringName, lexer.TokenNodePath,
+		lexer.TokenPI, lexer.TokenTAU, lexer.TokenINF, lexer.TokenNAN,
+		lexer.TokenTrue, lexer.TokenFalse, lexer.TokenNull:
+		return &Literal{node: p.nodeFrom(token), Kind: token.Type, Value: token.Value, Constant: token.Literal}
+	case lexer.TokenSelf:
+		return &SelfExpr{node: p.nodeFrom(token)}
+	case lexer.TokenSuper:
+		return &SuperExpr{node: p.nodeFrom(token)}
+	case lexer.TokenLParen:
+		expression := p.parseExpression()
+		p.expect(lexer.TokenRParen, "expected ')' after expression")
+		return expression
+	case lexer.TokenLBracket:
+		return p.parseArray(token)
+	case lexer.TokenLBrace:
+		return p.parseDictionary(token)
+	case lexer.TokenDash, lexer.TokenPlus:
+		return p.parseUnary(token, precSign)
+	case lexer.TokenTilda:
+		return p.parseUnary(token, precBitNot)
+	case lexer.TokenBang:
+		return p.parseUnary(token, precLogicNot)
+	case lexer.TokenAwait:
+		value := p.parsePrecedence(precAwait)
+		return &AwaitExpr{node: p.nodeFrom(token), Value: value}
+	case lexer.TokenPreload:
+		p.expect(lexer.TokenLParen, "expected '(' after 'preload'")
+		path := p.parseExpression()
+		p.expect(lexer.TokenRParen, "expected ')' after preload path")
+		return &PreloadExpr{node: p.nodeFrom(token), Path: path}
+	case lexer.TokenFunc:
+		return p.parseLambda(token)
+	case lexer.TokenDollar:
+		return p.parseGetNode(token, p.match(lexer.TokenPercent))
+	case lexer.TokenPercent:
+		return p.parseGetNode(token, true)
+	}
+
+	p.errorAt(token, fmt.Sprintf("expected an expression, found %s", describe(token)))
+	panic(bailout{})
+}
+
+func (p *Parser) parseUnary(operator lexer.Token, precedence int) Expression {
+	operand := p.parsePrecedence(precedence)
+
+	return &UnaryExpr{node: p.nodeFrom(operator), Operator: operator.Type, Operand: operand}
+}
+
+func (p *Parser) parseInfix(left Expression, precedence int) Expression {
+	operator := p.advance()
+	start := left.Span().Start
+
+	switch operator.Type {
+	case lexer.TokenIf:
+		condition := p.parsePrecedence(precTernary + 1)
+		p.expect(lexer.TokenElse, "expected 'else' in ternary expression")
+		other := p.parsePrecedence(precTernary)
+
+		return &TernaryExpr{node: p.nodeFromPosition(start), Condition: condition, True: left, False: other}
+	case lexer.TokenAs:
+		typ := p.parseType()
+		return &CastExpr{node: p.nodeFromPosition(start), Value: left, Type: typ}
+	case lexer.TokenIs:
+		negated := p.match(lexer.TokenBang)
+		typ := p.parseType()
+		return &TypeTestExpr{node: p.nodeFromPosition(start), Value: left, Type: typ, Negated: negated}
+	case lexer.TokenLParen:
+		arguments := p.parseArguments()
+		return &CallExpr{node: p.nodeFromPosition(start), Callee: left, Arguments: arguments}
+	case lexer.TokenPeriod:
+		name := p.parseAttributeName()
+		return &AttributeExpr{node: p.nodeFromPosition(start), Target: left, Name: name}
+	case lexer.TokenLBracket:
+		index := p.parseExpression()
+		p.expect(lexer.TokenRBracket, "expected ']' after subscript")
+		return &SubscriptExpr{node: p.nodeFromPosition(start), Target: left, Index: index}
+	}
+
+	// binary operators are all left associative, including '**' as in Godot
+	right := p.parsePrecedence(precedence + 1)
+
+	return &BinaryExpr{node: p.nodeFromPosition(start), Operator: operator.Type, Left: left, Right: right}
+}
+
+// parses the name after a '.', which may also be a keyword such as Object.is or Node.class
+func (p *Parser) parseAttributeName() *Identifier {
+	token := p.peek()
+	if _, isKeyword := lexer.GDScriptKeywords[token.Value]; isKeyword && token.Type != lexer.TokenIdentifier {
+		p.advance()
+		return &Identifier{node: p.nodeFrom(token), Name: token.Value}
+	}
+
+	return p.expectIdentifier("expected a name after '.'")
+}
+
+// parses the path of a $ or % node shorthand, either a quoted string
+// or names separated by '/'
+func (p *Parser) parseGetNode(start lexer.Token, unique bool) *GetNodeExpr {
+	expression := &GetNodeExpr{Unique: unique}
+
+	if p.check(lexer.TokenString) {
+		expression.Path = p.advance().Literal.(string)
+	} else {
+		parts := make([]string, 0)
+
+		for {
+			token := p.peek()
+			_, isKeyword := lexer.GDScriptKeywords[token.Value]
+
+			switch {
+			case token.Type == lexer.TokenIdentifier, isKeyword,
+				token.Type == lexer.TokenPeriod, token.Type == lexer.TokenPeriodPeriod:
+				parts = append(parts, p.advance().Value)
+			default:
+				p.fail("expected a node path")
+			}
+
+			if !p.match(lexer.TokenSlash) {
+				break
+			}
+		}
+
+		expression.Path = strings.Join(parts, "/")
+	}
+
+	expression.node = p.nodeFrom(start)
+	return expression
+}
+
+// parses call arguments after the opening '('
+func (p *Parser) parseArguments() []Expression {
+	arguments := make([]Expression, 0)
+
+	for !p.check(lexer.TokenRParen) {
+		arguments = append(arguments, p.parseExpression())
+		if !p.match(lexer.TokenComma) {
+			break
+		}
+	}
+
+	p.expect(lexer.TokenRParen, "expected ')' after arguments")
+
+	return arguments
+}
+
+func (p *Parser) parseArray(start lexer.Token) *ArrayLiteral {
+	array := &ArrayLiteral{Elements: make([]Expression, 0)}
+
+	for !p.check(lexer.TokenRBracket) {
+		array.Elements = append(array.Elements, p.parseExpression())
+		if !p.match(lexer.TokenComma) {
+			break
+		}
+	}
+
+	p.expect(lexer.TokenRBracket, "expected ']' after array elements")
+	array.node = p.nodeFrom(start)
+
+	return array
+}
+
+// parses a dictionary, with either Python style "key": value or Lua style key = value entries
+func (p *Parser) parseDictionary(start lexer.Token) *DictionaryLiteral {
+	dictionary := &DictionaryLiteral{Entries: make([]*DictionaryEntry, 0)}
+
+	for !p.check(lexer.TokenRBrace) {
+		key := p.parseExpression()
+
+		if p.match(lexer.TokenEquals) {
+			if _, ok := key.(*Identifier); !ok {
+				p.errorAt(p.previous(), "expected an identifier before '=' in dictionary")
+			}
+		} else {
+			p.expect(lexer.TokenColon, "expected ':' or '=' after dictionary key")
+		}
+
+		value := p.parseExpression()
+		dictionary.Entries = append(dictionary.Entries, &DictionaryEntry{
+			node:  p.nodeFromPosition(key.Span().Start),
+			Key:   key,
+			Value: value,
+		})
+
+		if !p.match(lexer.TokenComma) {
+			break
+		}
+	}
+
+	p.expect(lexer.TokenRBrace, "expected '}' after dictionary entries")
+	dictionary.node = p.nodeFrom(start)
+
+	return dictionary
+}
+
+// parses a lambda after the 'func' keyword, the body can be inline or an indented block
+func (p *Parser) parseLambda(start lexer.Token) *LambdaExpr {
+	lambda := &LambdaExpr{}
+
+	if p.check(lexer.TokenIdentifier) {
+		lambda.Name = p.expectIdentifier("expected a lambda name")
+	}
+
+	lambda.Parameters = p.parseParameters()
+
+	if p.match(lexer.TokenArrow) {
+		lambda.ReturnType = p.parseType()
+	}
+
+	lambda.Body = p.parseBlock()
+	lambda.node = p.nodeFrom(start)
+
+	return lambda
+}
@@ -0,0 +1,733 @@
+package parser
+
+import (
+	"fmt"
+
+	"gdx/analysis/lexer"
+)
+
+// annotations that apply to the whole class rather than the next member
+var classAnnotations = map[string]bool{
+	"tool":          true,
+	"icon":          true,
+	"static_unload": true,
+}
+
+// annotations that stand on their own in a class body
+var standaloneAnnotations = map[string]bool{
+	"export_category":        true,
+	"export_group":           true,
+	"export_subgroup":        true,
+	"warning_ignore_start":   true,
+	"warning_ignore_restore": true,
+}
+
+type SyntaxError struct {
+	Range   Range
+	Message string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at line %d: %s", e.Range.Start.Line, e.Message)
+}
+
+// used to unwind the parser back to the enclosing statement after a syntax error
+type bailout struct{}
+
+type Parser struct {
+	tokens  []lexer.Token
+	current int
+	// the end of the last consumed token that isn't a layout token
+	last   Position
+	errors []*SyntaxError
+}
+
+func NewParser(tokens []lexer.Token) *Parser {
+	return &Parser{
+		tokens:  tokens,
+		current: 0,
+		last:    Position{Line: 1},
+		errors:  make([]*SyntaxError, 0),
+	}
+}
+
+// parses a whole GDScript file. Syntax errors don't stop parsing, the parser
+// skips to the next line and the nodes it could build are still returned
+func (p *Parser) Parse() (*File, []*SyntaxError) {
+	var class classBody
+
+	for !p.isAtEnd() {
+		if p.match(lexer.TokenNewline) {
+			continue
+		}
+
+		// stray dedents only appear after an error left a block half parsed
+		if p.match(lexer.TokenDedent) {
+			continue
+		}
+
+		p.recoverable(func() {
+			p.parseMember(&class, true)
+		})
+	}
+
+	file := &File{
+		node:        node{Range: Range{Start: Position{Line: 1}, End: p.last}},
+		Annotations: class.annotations,
+		ClassName:   class.className,
+		Extends:     class.extends,
+		Members:     class.members,
+	}
+
+	return file, p.errors
+}
+
+// check if we are at the end of the tokens
+func (p *Parser) isAtEnd() bool {
+	return p.current >= len(p.tokens) || p.tokens[p.current].Type == lexer.TokenEOF
+}
+
+// peeks at the current token, returning an EOF token past the end
+func (p *Parser) peek() lexer.Token {
+	return p.peekAt(0)
+}
+
+// peeks at the token n tokens ahead of the current one
+func (p *Parser) peekAt(n int) lexer.Token {
+	if p.current+n >= len(p.tokens) {
+		return lexer.Token{Type: lexer.TokenEOF, Line: p.last.Line, Start: p.last, End: p.last}
+	}
+
+	return p.tokens[p.current+n]
+}
+
+// returns the most recently consumed token
+func (p *Parser) previous() lexer.Token {
+	if p.current == 0 {
+		return lexer.Token{Type: lexer.TokenEOF, Line: 1, Start: Position{Line: 1}, End: Position{Line: 1}}
+	}
+
+	return p.tokens[p.current-1]
+}
+
+// advance the parser, returning the consumed token
+func (p *Parser) advance() lexer.Token {
+	token := p.peek()
+	if p.isAtEnd() {
+		return token
+	}
+
+	p.current += 1
+	if !isLayout(token.Type) {
+		p.last = token.End
+	}
+
+	return token
+}
+
+func (p *Parser) check(tokenType lexer.TokenType) bool {
+	return !p.isAtEnd() && p.peek().Type == tokenType
+}
+
+// advances the parser if the current token is one of the given types
+func (p *Parser) match(tokenTypes ...lexer.TokenType) bool {
+	for _, tokenType := range tokenTypes {
+		if p.check(tokenType) {
+			p.advance()
+			return true
+		}
+	}
+
+	return false
+}
+
+// consumes a token of the given type or fails with the given message
+func (p *Parser) expect(tokenType lexer.TokenType, message string) lexer.Token {
+	if p.check(tokenType) {
+		return p.advance()
+	}
+
+	p.fail(message)
+	return lexer.Token{}
+}
+
+func (p *Parser) expectIdentifier(message string) *Identifier {
+	token := p.expect(lexer.TokenIdentifier, message)
+
+	return &Identifier{node: p.nodeFrom(token), Name: token.Value}
+}
+
+// records a syntax error at the given token. Only the first error on a line is kept
+// since later ones are usually caused by the first
+func (p *Parser) errorAt(token lexer.Token, message string) {
+	// the lexer has already reported an error for unknown tokens
+	if token.Type == lexer.TokenUnknown {
+		return
+	}
+
+	if len(p.errors) > 0 && p.errors[len(p.errors)-1].Range.Start.Line == token.Line {
+		return
+	}
+
+	p.errors = append(p.errors, &SyntaxError{
+		Range:   Range{Start: token.Start, End: token.End},
+		Message: message,
+	})
+}
+
+// records a syntax error at the current token and unwinds to the enclosing statement
+func (p *Parser) fail(message string) {
+	token := p.peek()
+	p.errorAt(token, fmt.Sprintf("%s, found %s", message, describe(token)))
+	panic(bailout{})
+}
+
+// runs a parse function, recovering from syntax errors by skipping the rest of the statement
+func (p *Parser) recoverable(parse func()) {
+	start := p.current
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+
+			p.synchronize()
+
+			if p.current == start && !p.isAtEnd() && !p.check(lexer.TokenDedent) {
+				p.advance()
+			}
+		}
+	}()
+
+	parse()
+}
+
+// skips to the start of the next statement, including any block that belongs to the current one
+func (p *Parser) synchronize() {
+	depth := 0
+
+	for !p.isAtEnd() {
+		switch p.peek().Type {
+		case lexer.TokenNewline:
+			p.advance()
+			if depth == 0 && !p.check(lexer.TokenIndent) {
+				return
+			}
+		case lexer.TokenIndent:
+			depth += 1
+			p.advance()
+		case lexer.TokenDedent:
+			if depth == 0 {
+				return
+			}
+
+			depth -= 1
+			p.advance()
+			if depth == 0 {
+				return
+			}
+		default:
+			p.advance()
+		}
+	}
+}
+
+// checks if the current token ends a simple statement
+func (p *Parser) atStatementEnd() bool {
+	switch p.peek().Type {
+	case lexer.TokenNewline, lexer.TokenSemicolon, lexer.TokenEOF, lexer.TokenDedent,
+		lexer.TokenRParen, lexer.TokenRBracket, lexer.TokenRBrace, lexer.TokenComma:
+		return true
+	}
+
+	return false
+}
+
+// consumes the end of a simple statement. Statements inside inline lambdas
+// end at the closing bracket or comma, which is left for the enclosing expression
+func (p *Parser) endStatement() {
+	if p.match(lexer.TokenSemicolon, lexer.TokenNewline) {
+		return
+	}
+
+	if p.atStatementEnd() {
+		return
+	}
+
+	// the statement ended with a block, such as a multiline lambda
+	switch p.previous().Type {
+	case lexer.TokenNewline, lexer.TokenDedent:
+		return
+	}
+
+	p.fail("expected end of statement")
+}
+
+// creates a node spanning from the given token to the last consumed token
+func (p *Parser) nodeFrom(token lexer.Token) node {
+	return p.nodeFromPosition(token.Start)
+}
+
+func (p *Parser) nodeFromPosition(start Position) node {
+	return node{Range: Range{Start: start, End: p.last}}
+}
+
+func isLayout(tokenType lexer.TokenType) bool {
+	return tokenType == lexer.TokenNewline || tokenType == lexer.TokenIndent || tokenType == lexer.TokenDedent
+}
+
+// describes a token for use in error messages
+func describe(token lexer.Token) string {
+	switch token.Type {
+	case lexer.TokenEOF:
+		return "end of file"
+	case lexer.TokenNewline:
+		return "end of line"
+	case lexer.TokenIndent:
+		return "indented block"
+	case lexer.TokenDedent:
+		return "end of block"
+	}
+
+	return fmt.Sprintf("'%s'", token.Value)
+}
+
+// the parts of a class body collected while parsing its members
+type classBody struct {
+	annotations []*Annotation
+	className   *Identifier
+	extends     *ExtendsClause
+	members     []Member
+}
+
+// parses a single class member along with any annotations before it
+func (p *Parser) parseMember(class *classBody, topLevel bool) {
+	annotations := make([]*Annotation, 0)
+	for _, annotation := range p.parseAnnotations() {
+		switch {
+		case classAnnotations[annotation.Name.Name]:
+			class.annotations = append(class.annotations, annotation)
+		case standaloneAnnotations[annotation.Name.Name]:
+			class.members = append(class.members, annotation)
+		default:
+			annotations = append(annotations, annotation)
+		}
+	}
+
+	switch p.peek().Type {
+	case lexer.TokenClassName:
+		start := p.advance()
+		if !topLevel {
+			p.errorAt(start, "class_name can only be used at the top of a script")
+		}
+
+		class.className = p.expectIdentifier("expected a class name after class_name")
+		if p.check(lexer.TokenExtends) {
+			class.extends = p.parseExtends()
+		}
+		p.endStatement()
+	case lexer.TokenExtends:
+		class.extends = p.parseExtends()
+		if p.match(lexer.TokenClassName) {
+			class.className = p.expectIdentifier("expected a class name after class_name")
+		}
+		p.endStatement()
+	case lexer.TokenVar:
+		class.members = append(class.members, p.parseVar(annotations, false, true))
+	case lexer.TokenStatic:
+		p.advance()
+		switch p.peek().Type {
+		case lexer.TokenVar:
+			class.members = append(class.members, p.parseVar(annotations, true, true))
+		case lexer.TokenFunc:
+			class.members = append(class.members, p.parseFunc(annotations, true))
+		default:
+			p.fail("expected 'var' or 'func' after 'static'")
+		}
+	case lexer.TokenConst:
+		class.members = append(class.members, p.parseConst(annotations))
+	case lexer.TokenSignal:
+		class.members = append(class.members, p.parseSignal(annotations))
+	case lexer.TokenEnum:
+		class.members = append(class.members, p.parseEnum(annotations))
+	case lexer.TokenFunc:
+		class.members = append(class.members, p.parseFunc(annotations, false))
+	case lexer.TokenClass:
+		class.members = append(class.members, p.parseClass(annotations))
+	case lexer.TokenPass:
+		p.advance()
+		p.endStatement()
+	case lexer.TokenEOF, lexer.TokenDedent:
+		// annotations at the end of a class body have nothing to apply to
+		for _, annotation := range annotations {
+			class.members = append(class.members, annotation)
+		}
+	default:
+		p.fail("expected a class member declaration")
+	}
+}
+
+// parses any annotations at the current position, which may be on their own lines
+func (p *Parser) parseAnnotations() []*Annotation {
+	annotations := make([]*Annotation, 0)
+
+	for p.check(lexer.TokenAnnotation) {
+		start := p.advance()
+
+		// the name is the annotation token without its '@'
+		nameStart := start.Start
+		nameStart.Column += 1
+		nameStart.Offset += 1
+		name := &Identifier{
+			node: node{Range: Range{Start: nameStart, End: start.End}},
+			Name: start.Value[1:],
+		}
+
+		var arguments []Expression
+		if p.match(lexer.TokenLParen) {
+			arguments = p.parseArguments()
+		}
+
+		annotations = append(annotations, &Annotation{
+			node:      p.nodeFrom(start),
+			Name:      name,
+			Arguments: arguments,
+		})
+
+		for p.match(lexer.TokenNewline) {
+		}
+	}
+
+	return annotations
+}
+
+func (p *Parser) parseExtends() *ExtendsClause {
+	start := p.advance()
+	clause := &ExtendsClause{}
+
+	if p.check(lexer.TokenString) {
+		token := p.advance()
+		clause.Path = &Literal{node: p.nodeFrom(token), Kind: token.Type, Value: token.Value, Constant: token.Literal}
+
+		for p.match(lexer.TokenPeriod) {
+			clause.Names = append(clause.Names, p.expectIdentifier("expected a class name after '.'"))
+		}
+	} else {
+		clause.Names = append(clause.Names, p.expectIdentifier("expected a class name or path after 'extends'"))
+
+		for p.match(lexer.TokenPeriod) {
+			clause.Names = append(clause.Names, p.expectIdentifier("expected a class name after '.'"))
+		}
+	}
+
+	clause.node = p.nodeFrom(start)
+	return clause
+}
+
+func (p *Parser) parseClass(annotations []*Annotation) *ClassDecl {
+	start := p.advance()
+	class := &ClassDecl{
+		Annotations: annotations,
+		Name:        p.expectIdentifier("expected a class name after 'class'"),
+	}
+
+	var body classBody
+	if p.check(lexer.TokenExtends) {
+		body.extends = p.parseExtends()
+	}
+
+	p.expect(lexer.TokenColon, "expected ':' after class declaration")
+
+	if p.match(lexer.TokenNewline) {
+		p.expect(lexer.TokenIndent, "expected an indented class body")
+
+		for !p.check(lexer.TokenDedent) && !p.isAtEnd() {
+			if p.match(lexer.TokenNewline) {
+				continue
+			}
+
+			p.recoverable(func() {
+				p.parseMember(&body, false)
+			})
+		}
+
+		p.match(lexer.TokenDedent)
+	} else {
+		p.parseMember(&body, false)
+	}
+
+	class.Annotations = append(class.Annotations, body.annotations...)
+	class.Extends = body.extends
+	class.Members = body.members
+	class.node = p.nodeFrom(start)
+
+	return class
+}
+
+// parses a variable declaration. Only class members can have a setter and getter
+func (p *Parser) parseVar(annotations []*Annotation, static bool, member bool) *VarDecl {
+	start := p.advance()
+	decl := &VarDecl{
+		Annotations: annotations,
+		Static:      static,
+		Name:        p.expectIdentifier("expected a variable name after 'var'"),
+	}
+
+	if p.check(lexer.TokenColon) && !(member && p.peekAt(1).Type == lexer.TokenNewline) {
+		p.advance()
+
+		if p.check(lexer.TokenEquals) {
+			decl.Inferred = true
+		} else {
+			decl.Type = p.parseType()
+		}
+	}
+
+	if p.match(lexer.TokenColonEquals) {
+		decl.Inferred = true
+		decl.Value = p.parseExpression()
+	} else if p.match(lexer.TokenEquals) {
+		decl.Value = p.parseExpression()
+	}
+
+	if member && p.check(lexer.TokenColon) {
+		p.parseAccessors(decl)
+	}
+
+	decl.node = p.nodeFrom(start)
+	p.endStatement()
+
+	return decl
+}
+
+// parses the setter and getter of a property, either inline or as an indented block
+func (p *Parser) parseAccessors(decl *VarDecl) {
+	p.advance()
+
+	if !p.match(lexer.TokenNewline) {
+		p.parseAccessor(decl)
+		for p.match(lexer.TokenComma) {
+			p.parseAccessor(decl)
+		}
+		return
+	}
+
+	p.expect(lexer.TokenIndent, "expected an indented block of property accessors")
+
+	for !p.check(lexer.TokenDedent) && !p.isAtEnd() {
+		if p.match(lexer.TokenNewline) {
+			continue
+		}
+
+		// accessors naming functions can share a line, as in get = get_x, set = set_x
+		p.recoverable(func() {
+			p.parseAccessor(decl)
+			for p.match(lexer.TokenComma) {
+				p.parseAccessor(decl)
+			}
+		})
+	}
+
+	p.match(lexer.TokenDedent)
+}
+
+func (p *Parser) parseAccessor(decl *VarDecl) {
+	start := p.peek()
+	name := p.expectIdentifier("expected 'set' or 'get'")
+
+	accessor := &Accessor{}
+
+	if p.match(lexer.TokenEquals) {
+		accessor.Function = p.expectIdentifier("expected a function name")
+	} else {
+		switch name.Name {
+		case "set":
+			p.expect(lexer.TokenLParen, "expected '(' after 'set'")
+			accessor.Parameter = p.expectIdentifier("expected a setter parameter name")
+			p.expect(lexer.TokenRParen, "expected ')' after setter parameter")
+		case "get":
+			if p.match(lexer.TokenLParen) {
+				p.expect(lexer.TokenRParen, "expected ')' after '('")
+			}
+		}
+
+		accessor.Body = p.parseBlock()
+	}
+
+	accessor.node = p.nodeFrom(start)
+
+	switch name.Name {
+	case "set":
+		decl.Setter = accessor
+	case "get":
+		decl.Getter = accessor
+	default:
+		p.errorAt(start, fmt.Sprintf("expected 'set' or 'get', found '%s'", name.Name))
+	}
+}
+
+func (p *Parser) parseConst(annotations []*Annotation) *ConstDecl {
+	start := p.advance()
+	decl := &ConstDecl{
+		Annotations: annotations,
+		Name:        p.expectIdentifier("expected a constant name after 'const'"),
+	}
+
+	if p.match(lexer.TokenColonEquals) {
+		decl.Inferred = true
+	} else {
+		if p.match(lexer.TokenColon) {
+			if p.check(lexer.TokenEquals) {
+				decl.Inferred = true
+			} else {
+				decl.Type = p.parseType()
+			}
+		}
+
+		p.expect(lexer.TokenEquals, "expected '=' after constant name")
+	}
+
+	decl.Value = p.parseExpression()
+	decl.node = p.nodeFrom(start)
+	p.endStatement()
+
+	return decl
+}
+
+func (p *Parser) parseSignal(annotations []*Annotation) *SignalDecl {
+	start := p.advance()
+	decl := &SignalDecl{
+		Annotations: annotations,
+		Name:        p.expectIdentifier("expected a signal name after 'signal'"),
+	}
+
+	if p.check(lexer.TokenLParen) {
+		decl.Parameters = p.parseParameters()
+	}
+
+	decl.node = p.nodeFrom(start)
+	p.endStatement()
+
+	return decl
+}
+
+func (p *Parser) parseEnum(annotations []*Annotation) *EnumDecl {
+	start := p.advance()
+	decl := &EnumDecl{Annotations: annotations}
+
+	if p.check(lexer.TokenIdentifier) {
+		decl.Name = p.expectIdentifier("expected an enum name")
+	}
+
+	p.expect(lexer.TokenLBrace, "expected '{' after enum")
+
+	for !p.check(lexer.TokenRBrace) {
+		name := p.expectIdentifier("expected an enum value name")
+		value := &EnumValue{Name: name}
+
+		if p.match(lexer.TokenEquals) {
+			value.Value = p.parseExpression()
+		}
+
+		value.node = p.nodeFromPosition(name.Range.Start)
+		decl.Values = append(decl.Values, value)
+
+		if !p.match(lexer.TokenComma) {
+			break
+		}
+	}
+
+	p.expect(lexer.TokenRBrace, "expected '}' after enum values")
+	decl.node = p.nodeFrom(start)
+	p.endStatement()
+
+	return decl
+}
+
+func (p *Parser) parseFunc(annotations []*Annotation, static bool) *FuncDecl {
+	start := p.advance()
+	decl := &FuncDecl{
+		Annotations: annotations,
+		Static:      static,
+		Name:        p.expectIdentifier("expected a function name after 'func'"),
+	}
+
+	decl.Parameters = p.parseParameters()
+
+	if p.match(lexer.TokenArrow) {
+		decl.ReturnType = p.parseType()
+	}
+
+	decl.Body = p.parseBlock()
+	decl.node = p.nodeFrom(start)
+
+	return decl
+}
+
+// parses a parenthesised parameter list for functions, lambdas and signals
+func (p *Parser) parseParameters() []*Parameter {
+	p.expect(lexer.TokenLParen, "expected '(' before parameters")
+	parameters := make([]*Parameter, 0)
+
+	for !p.check(lexer.TokenRParen) {
+		name := p.expectIdentifier("expected a parameter name")
+		parameter := &Parameter{Name: name}
+
+		if p.match(lexer.TokenColon) {
+			if p.check(lexer.TokenEquals) {
+				parameter.Inferred = true
+			} else {
+				parameter.Type = p.parseType()
+			}
+		}
+
+		if p.match(lexer.TokenColonEquals) {
+			parameter.Inferred = true
+			parameter.Default = p.parseExpression()
+		} else if p.match(lexer.TokenEquals) {
+			parameter.Default = p.parseExpression()
+		}
+
+		parameter.node = p.nodeFromPosition(name.Range.Start)
+		parameters = append(parameters, parameter)
+
+		if !p.match(lexer.TokenComma) {
+			break
+		}
+	}
+
+	p.expect(lexer.TokenRParen, "expected ')' after parameters")
+
+	return parameters
+}
+
+// parses a type hint
+func (p *Parser) parseType() *TypeExpr {
+	start := p.peek()
+	typ := &TypeExpr{}
+
+	if p.match(lexer.TokenVoid) {
+		typ.Names = append(typ.Names, &Identifier{node: p.nodeFrom(start), Name: start.Value})
+	} else {
+		typ.Names = append(typ.Names, p.expectIdentifier("expected a type"))
+
+		for p.check(lexer.TokenPeriod) && p.peekAt(1).Type == lexer.TokenIdentifier {
+			p.advance()
+			typ.Names = append(typ.Names, p.expectIdentifier("expected a type"))
+		}
+	}
+
+	// typed collections such as Array[int] and Dictionary[String, int]
+	if p.match(lexer.TokenLBracket) {
+		typ.Elements = append(typ.Elements, p.parseType())
+		for p.match(lexer.TokenComma) {
+			typ.Elements = append(typ.Elements, p.parseType())
+		}
+
+		p.expect(lexer.TokenRBracket, "expected ']' after element type")
+	}
+
+	typ.node = p.nodeFrom(start)
+	return typ
+}
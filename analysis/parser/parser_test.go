@@ -0,0 +1,414 @@
+package parser_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gdx/analysis/lexer"
+	"gdx/analysis/parser"
+)
+
+func parse(t *testing.T, source string) (*parser.File, []*parser.SyntaxError) {
+	t.Helper()
+
+	tokens, errs := lexer.NewScanner(source).ScanTokens()
+	if len(errs) > 0 {
+		t.Fatalf("unexpected lexical errors: %v", errs)
+	}
+
+	return parser.NewParser(tokens).Parse()
+}
+
+var operators = map[lexer.TokenType]string{
+	lexer.TokenPlus:         "+",
+	lexer.TokenDash:         "-",
+	lexer.TokenStar:         "*",
+	lexer.TokenPower:        "**",
+	lexer.TokenEqualsEquals: "==",
+	lexer.TokenLess:         "<",
+	lexer.TokenBooleanAnd:   "&&",
+	lexer.TokenBooleanOr:    "||",
+	lexer.TokenBang:         "!",
+	lexer.TokenIn:           "in",
+}
+
+// renders an expression as an s-expression so tests can check its structure
+func sexpr(expression parser.Expression) string {
+	switch e := expression.(type) {
+	case *parser.Identifier:
+		return e.Name
+	case *parser.Literal:
+		return e.Value
+	case *parser.UnaryExpr:
+		return fmt.Sprintf("(%s %s)", operators[e.Operator], sexpr(e.Operand))
+	case *parser.BinaryExpr:
+		return fmt.Sprintf("(%s %s %s)", operators[e.Operator], sexpr(e.Left), sexpr(e.Right))
+	case *parser.TernaryExpr:
+		return fmt.Sprintf("(if %s %s %s)", sexpr(e.Condition), sexpr(e.True), sexpr(e.False))
+	case *parser.CastExpr:
+		return fmt.Sprintf("(as %s %s)", sexpr(e.Value), e.Type.Names[0].Name)
+	case *parser.TypeTestExpr:
+		if e.Negated {
+			return fmt.Sprintf("(is-not %s %s)", sexpr(e.Value), e.Type.Names[0].Name)
+		}
+		return fmt.Sprintf("(is %s %s)", sexpr(e.Value), e.Type.Names[0].Name)
+	case *parser.GetNodeExpr:
+		if e.Unique {
+			return "%" + e.Path
+		}
+		return "$" + e.Path
+	case *parser.AwaitExpr:
+		return fmt.Sprintf("(await %s)", sexpr(e.Value))
+	case *parser.AttributeExpr:
+		return fmt.Sprintf("(. %s %s)", sexpr(e.Target), e.Name.Name)
+	case *parser.SubscriptExpr:
+		return fmt.Sprintf("([] %s %s)", sexpr(e.Target), sexpr(e.Index))
+	case *parser.CallExpr:
+		arguments := make([]string, 0)
+		for _, argument := range e.Arguments {
+			arguments = append(arguments, sexpr(argument))
+		}
+		return fmt.Sprintf("(call %s%s)", sexpr(e.Callee), strings.Join(append([]string{""}, arguments...), " "))
+	case *parser.ArrayLiteral:
+		elements := make([]string, 0)
+		for _, element := range e.Elements {
+			elements = append(elements, sexpr(element))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(elements, " "))
+	case *parser.DictionaryLiteral:
+		entries := make([]string, 0)
+		for _, entry := range e.Entries {
+			entries = append(entries, sexpr(entry.Key)+":"+sexpr(entry.Value))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(entries, " "))
+	case *parser.LambdaExpr:
+		return fmt.Sprintf("(lambda %d)", len(e.Body.Statements))
+	case *parser.SelfExpr:
+		return "self"
+	}
+
+	return fmt.Sprintf("%T", expression)
+}
+
+func TestParseExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "1 + 2 * 3", expected: "(+ 1 (* 2 3))"},
+		{input: "(1 + 2) * 3", expected: "(* (+ 1 2) 3)"},
+		{input: "1 - 2 - 3", expected: "(- (- 1 2) 3)"},
+		{input: "2 ** 3 ** 2", expected: "(** (** 2 3) 2)"},
+		{input: "-2 ** 2", expected: "(- (** 2 2))"},
+		{input: "!a == b", expected: "(! (== a b))"},
+		{input: "a || b && c", expected: "(|| a (&& b c))"},
+		{input: "a if b else c if d else e", expected: "(if b a (if d c e))"},
+		{input: "x in list && y", expected: "(&& (in x list) y)"},
+		{input: "node as Node2D", expected: "(as node Node2D)"},
+		{input: "node is Node2D == true", expected: "(== (is node Node2D) true)"},
+		{input: "await get_tree().process_frame", expected: "(await (. (call get_tree) process_frame))"},
+		{input: "self.items[0].name", expected: "(. ([] (. self items) 0) name)"},
+		{input: "foo(1, bar(2),)", expected: "(call foo 1 (call bar 2))"},
+		{input: "[1, 2,\n\t3]", expected: "[1 2 3]"},
+		{input: "{\"a\": 1, b = 2}", expected: "{a:1 b:2}"},
+		{input: "func(x): return x", expected: "(lambda 1)"},
+		{input: "a and b or not c", expected: "(|| (&& a b) (! c))"},
+		{input: "x not in list and y", expected: "(&& (! (in x list)) y)"},
+		{input: "node is not Node2D", expected: "(is-not node Node2D)"},
+		{input: "$Path/To/Node.position", expected: "(. $Path/To/Node position)"},
+		{input: "$\"Path/To\"", expected: "$Path/To"},
+		{input: "%Unique.name + $%Other", expected: "(+ (. %Unique name) %Other)"},
+		{input: "{&\"key\": ^\"Node\", \"b\": null}", expected: "{key:Node b:null}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			file, errors := parse(t, "var x = "+test.input+"\n")
+			if len(errors) > 0 {
+				t.Fatalf("unexpected errors: %v", errors)
+			}
+
+			decl := file.Members[0].(*parser.VarDecl)
+			if actual := sexpr(decl.Value); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseDeclarations(t *testing.T) {
+	source := `@tool
+class_name Player extends CharacterBody2D
+
+signal hit(damage: int)
+enum State { IDLE, RUNNING = 2, }
+const SPEED := 300.0
+
+@export_group("Stats")
+@export var health: int = 100
+@onready var sprite := get_node("Sprite")
+var items: Array[String] = []
+var speed: float = 1.0:
+	set(value):
+		speed = value
+	get:
+		return speed
+var other: int: set = set_other, get = get_other
+var block: int:
+	get = get_block, set = set_block
+
+static func create() -> Player:
+	return Player.new()
+
+class Inner extends "res://base.gd".Base:
+	var a = 1
+`
+	file, errors := parse(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+
+	if file.ClassName == nil || file.ClassName.Name != "Player" {
+		t.Fatalf("expected class name Player, got %+v", file.ClassName)
+	}
+
+	if file.Extends == nil || file.Extends.Names[0].Name != "CharacterBody2D" {
+		t.Fatalf("expected extends CharacterBody2D, got %+v", file.Extends)
+	}
+
+	if len(file.Annotations) != 1 || file.Annotations[0].Name.Name != "tool" {
+		t.Errorf("expected @tool class annotation, got %+v", file.Annotations)
+	}
+
+	expectedMembers := []string{
+		"*parser.SignalDecl",
+		"*parser.EnumDecl",
+		"*parser.ConstDecl",
+		"*parser.Annotation",
+		"*parser.VarDecl",
+		"*parser.VarDecl",
+		"*parser.VarDecl",
+		"*parser.VarDecl",
+		"*parser.VarDecl",
+		"*parser.VarDecl",
+		"*parser.FuncDecl",
+		"*parser.ClassDecl",
+	}
+
+	actualMembers := make([]string, 0)
+	for _, member := range file.Members {
+		actualMembers = append(actualMembers, fmt.Sprintf("%T", member))
+	}
+
+	if !reflect.DeepEqual(actualMembers, expectedMembers) {
+		t.Fatalf("expected members %v, got %v", expectedMembers, actualMembers)
+	}
+
+	signal := file.Members[0].(*parser.SignalDecl)
+	if signal.Name.Name != "hit" || len(signal.Parameters) != 1 || signal.Parameters[0].Type.Names[0].Name != "int" {
+		t.Errorf("unexpected signal %+v", signal)
+	}
+
+	enum := file.Members[1].(*parser.EnumDecl)
+	if enum.Name.Name != "State" || len(enum.Values) != 2 || enum.Values[1].Value == nil {
+		t.Errorf("unexpected enum %+v", enum)
+	}
+
+	constant := file.Members[2].(*parser.ConstDecl)
+	if !constant.Inferred {
+		t.Errorf("expected SPEED to have an inferred type")
+	}
+
+	health := file.Members[4].(*parser.VarDecl)
+	if len(health.Annotations) != 1 || health.Annotations[0].Name.Name != "export" || health.Type.Names[0].Name != "int" {
+		t.Errorf("unexpected health declaration %+v", health)
+	}
+
+	items := file.Members[6].(*parser.VarDecl)
+	if items.Type.Names[0].Name != "Array" || items.Type.Elements[0].Names[0].Name != "String" {
+		t.Errorf("expected Array[String], got %+v", items.Type)
+	}
+
+	speed := file.Members[7].(*parser.VarDecl)
+	if speed.Setter == nil || speed.Setter.Parameter.Name != "value" || speed.Getter == nil || speed.Getter.Body == nil {
+		t.Errorf("expected a setter and getter with bodies, got %+v", speed)
+	}
+
+	if speed.Span().Start.Line != 12 || speed.Span().End.Line != 16 {
+		t.Errorf("expected speed to span lines 12-16, got %+v", speed.Span())
+	}
+
+	other := file.Members[8].(*parser.VarDecl)
+	if other.Setter.Function.Name != "set_other" || other.Getter.Function.Name != "get_other" {
+		t.Errorf("expected inline accessors, got %+v", other)
+	}
+
+	block := file.Members[9].(*parser.VarDecl)
+	if block.Getter == nil || block.Getter.Function.Name != "get_block" || block.Setter == nil || block.Setter.Function.Name != "set_block" {
+		t.Errorf("expected accessors sharing a line in a block, got %+v", block)
+	}
+
+	create := file.Members[10].(*parser.FuncDecl)
+	if !create.Static || create.ReturnType.Names[0].Name != "Player" || len(create.Body.Statements) != 1 {
+		t.Errorf("unexpected function %+v", create)
+	}
+
+	inner := file.Members[11].(*parser.ClassDecl)
+	if inner.Name.Name != "Inner" || inner.Extends.Path.Value != "res://base.gd" || len(inner.Members) != 1 {
+		t.Errorf("unexpected inner class %+v", inner)
+	}
+}
+
+func TestParseStatements(t *testing.T) {
+	source := `func _ready():
+	var total := 0
+	for i: int in range(10):
+		total += i
+	while total > 0:
+		total -= 1
+	if total == 0:
+		pass
+	elif total < 0:
+		return
+	else:
+		breakpoint
+	if done: print("a"); print("b")
+	assert(total == 0, "total should be zero")
+	button.pressed.connect(func():
+		print("pressed")
+		total += 1
+	)
+	match total:
+		0, 1:
+			pass
+		[var first, ..]:
+			print(first)
+		{"key": var value, ..}:
+			print(value)
+		var other when other > 3:
+			pass
+		_:
+			pass
+`
+
+	file, errors := parse(t, source)
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+
+	body := file.Members[0].(*parser.FuncDecl).Body
+
+	expected := []string{
+		"*parser.VarDecl",
+		"*parser.ForStmt",
+		"*parser.WhileStmt",
+		"*parser.IfStmt",
+		"*parser.IfStmt",
+		"*parser.AssertStmt",
+		"*parser.ExpressionStmt",
+		"*parser.MatchStmt",
+	}
+
+	actual := make([]string, 0)
+	for _, statement := range body.Statements {
+		actual = append(actual, fmt.Sprintf("%T", statement))
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected statements %v, got %v", expected, actual)
+	}
+
+	ifStmt := body.Statements[3].(*parser.IfStmt)
+	elif, ok := ifStmt.Else.(*parser.IfStmt)
+	if !ok {
+		t.Fatalf("expected elif branch, got %T", ifStmt.Else)
+	}
+
+	if _, ok := elif.Else.(*parser.Block); !ok {
+		t.Errorf("expected else block, got %T", elif.Else)
+	}
+
+	inline := body.Statements[4].(*parser.IfStmt)
+	if len(inline.Body.Statements) != 2 {
+		t.Errorf("expected 2 inline statements, got %d", len(inline.Body.Statements))
+	}
+
+	call := body.Statements[6].(*parser.ExpressionStmt).Expression.(*parser.CallExpr)
+	lambda := call.Arguments[0].(*parser.LambdaExpr)
+	if len(lambda.Body.Statements) != 2 {
+		t.Errorf("expected a lambda with 2 statements, got %d", len(lambda.Body.Statements))
+	}
+
+	match := body.Statements[7].(*parser.MatchStmt)
+	expectedPatterns := []string{
+		"*parser.ExpressionPattern",
+		"*parser.ArrayPattern",
+		"*parser.DictionaryPattern",
+		"*parser.BindingPattern",
+		"*parser.WildcardPattern",
+	}
+
+	actualPatterns := make([]string, 0)
+	for _, branch := range match.Branches {
+		actualPatterns = append(actualPatterns, fmt.Sprintf("%T", branch.Patterns[0]))
+	}
+
+	if !reflect.DeepEqual(actualPatterns, expectedPatterns) {
+		t.Errorf("expected patterns %v, got %v", expectedPatterns, actualPatterns)
+	}
+
+	if match.Branches[3].Guard == nil {
+		t.Errorf("expected a guard on the binding branch")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedLines []int
+	}{
+		{
+			name:          "Missing colon",
+			input:         "func f()\n\tpass\n",
+			expectedLines: []int{1},
+		},
+		{
+			name:          "Recovers after bad statement",
+			input:         "func f():\n\tif x\n\t\tpass\n\treturn 1 2\nvar ok = 1\n",
+			expectedLines: []int{2, 4},
+		},
+		{
+			name:          "Yield",
+			input:         "func f():\n\tyield(self, \"done\")\n",
+			expectedLines: []int{2},
+		},
+		{
+			name:          "Unexpected member",
+			input:         "var x = 1\nreturn x\nvar y = 2\n",
+			expectedLines: []int{2},
+		},
+		{
+			name:          "Valid",
+			input:         "extends Node\n\nfunc f(a, b := 2, c: int = 3) -> void:\n\tpass\n",
+			expectedLines: []int{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, errors := parse(t, test.input)
+
+			actual := make([]int, 0)
+			for _, err := range errors {
+				actual = append(actual, err.Range.Start.Line)
+			}
+
+			if !reflect.DeepEqual(actual, test.expectedLines) {
+				t.Errorf("expected errors on lines %v, got %v", test.expectedLines, errors)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package parser
+
+import (
+	"gdx/analysis/lexer"
+)
+
+var assignmentOperators = map[lexer.TokenType]bool{
+	lexer.TokenEquals:      true,
+	lexer.TokenPlusEqual:   true,
+	lexer.TokenMinusEqual:  true,
+	lexer.TokenTimesEqual:  true,
+	lexer.TokenDivideEqual: true,
+	lexer.TokenPowerEqual:  true,
+	lexer.TokenModEqual:    true,
+	lexer.TokenAndEqual:    true,
+	lexer.TokenOrEqual:     true,
+	lexer.TokenXorEqual:    true,
+	lexer.TokenRShiftEqual: true,
+	lexer.TokenLShiftEqual: true,
+}
+
+// parses the block after a ':', which is either an indented block on the
+// following lines or statements on the same line separated by semicolons
+func (p *Parser) parseBlock() *Block {
+	start := p.expect(lexer.TokenColon, "expected ':' before block")
+	block := &Block{Statements: make([]Statement, 0)}
+
+	if p.match(lexer.TokenNewline) {
+		p.expect(lexer.TokenIndent, "expected an indented block")
+
+		for !p.check(lexer.TokenDedent) && !p.isAtEnd() {
+			if p.match(lexer.TokenNewline) {
+				continue
+			}
+
+			p.recoverable(func() {
+				block.Statements = append(block.Statements, p.parseStatement())
+			})
+		}
+
+		p.match(lexer.TokenDedent)
+	} else {
+		for {
+			p.recoverable(func() {
+				block.Statements = append(block.Statements, p.parseStatement())
+			})
+
+			if p.previous().Type != lexer.TokenSemicolon || p.atStatementEnd() {
+				p.match(lexer.TokenNewline)
+				break
+			}
+		}
+	}
+
+	block.node = p.nodeFrom(start)
+	return block
+}
+
+func (p *Parser) parseStatement() Statement {
+	switch p.peek().Type {
+	case lexer.TokenAnnotation:
+		annotations := p.parseAnnotations()
+		if p.check(lexer.TokenVar) {
+			return p.parseVar(annotations, false, false)
+		}
+
+		return p.parseStatement()
+	case lexer.TokenVar:
+		return p.parseVar(nil, false, false)
+	case lexer.TokenConst:
+		return p.parseConst(nil)
+	case lexer.TokenIf:
+		return p.parseIf()
+	case lexer.TokenFor:
+		return p.parseFor()
+	case lexer.TokenWhile:
+		return p.parseWhile()
+	case lexer.TokenMatch:
+		return p.parseMatch()
+	case lexer.TokenReturn:
+		start := p.advance()
+		stmt := &ReturnStmt{}
+		if !p.atStatementEnd() {
+			stmt.Value = p.parseExpression()
+		}
+
+		stmt.node = p.nodeFrom(start)
+		p.endStatement()
+		return stmt
+	case lexer.TokenAssert:
+		return p.parseAssert()
+	case lexer.TokenPass:
+		stmt := &PassStmt{node: p.nodeFrom(p.advance())}
+		p.endStatement()
+		return stmt
+	case lexer.TokenBreak:
+		stmt := &BreakStmt{node: p.nodeFrom(p.advance())}
+		p.endStatement()
+		return stmt
+	case lexer.TokenContinue:
+		stmt := &ContinueStmt{node: p.nodeFrom(p.advance())}
+		p.endStatement()
+		return stmt
+	case lexer.TokenBreakpoint:
+		stmt := &BreakpointStmt{node: p.nodeFrom(p.advance())}
+		p.endStatement()
+		return stmt
+	}
+
+	expression := p.parseExpression()
+
+	var stmt Statement
+	if assignmentOperators[p.peek().Type] {
+		operator := p.advance()
+		value := p.parseExpression()
+
+		stmt = &AssignStmt{
+			node:     p.nodeFromPosition(expression.Span().Start),
+			Target:   expression,
+			Operator: operator.Type,
+			Value:    value,
+		}
+	} else {
+		stmt = &ExpressionStmt{
+			node:       p.nodeFromPosition(expression.Span().Start),
+			Expression: expression,
+		}
+	}
+
+	p.endStatement()
+	return stmt
+}
+
+// parses an if statement along with its elif and else branches
+func (p *Parser) parseIf() *IfStmt {
+	start := p.advance()
+	stmt := &IfStmt{
+		Condition: p.parseExpression(),
+		Body:      p.parseBlock(),
+	}
+
+	if p.check(lexer.TokenElif) {
+		stmt.Else = p.parseIf()
+	} else if p.match(lexer.TokenElse) {
+		stmt.Else = p.parseBlock()
+	}
+
+	stmt.node = p.nodeFrom(start)
+	return stmt
+}
+
+func (p *Parser) parseFor() *ForStmt {
+	start := p.advance()
+	stmt := &ForStmt{
+		Variable: p.expectIdentifier("expected a loop variable after 'for'"),
+	}
+
+	if p.match(lexer.TokenColon) {
+		stmt.VariableType = p.parseType()
+	}
+
+	p.expect(lexer.TokenIn, "expected 'in' after loop variable")
+	stmt.Iterable = p.parseExpression()
+	stmt.Body = p.parseBlock()
+	stmt.node = p.nodeFrom(start)
+
+	return stmt
+}
+
+func (p *Parser) parseWhile() *WhileStmt {
+	start := p.advance()
+	stmt := &WhileStmt{
+		Condition: p.parseExpression(),
+		Body:      p.parseBlock(),
+	}
+
+	stmt.node = p.nodeFrom(start)
+	return stmt
+}
+
+func (p *Parser) parseAssert() *AssertStmt {
+	start := p.advance()
+	stmt := &AssertStmt{}
+
+	p.expect(lexer.TokenLParen, "expected '(' after 'assert'")
+	stmt.Condition = p.parseExpression()
+	if p.match(lexer.TokenComma) && !p.check(lexer.TokenRParen) {
+		stmt.Message = p.parseExpression()
+		p.match(lexer.TokenComma)
+	}
+	p.expect(lexer.TokenRParen, "expected ')' after assert arguments")
+
+	stmt.node = p.nodeFrom(start)
+	p.endStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseMatch() *MatchStmt {
+	start := p.advance()
+	stmt := &MatchStmt{Subject: p.parseExpression()}
+
+	p.expect(lexer.TokenColon, "expected ':' after match subject")
+	p.expect(lexer.TokenNewline, "expected a new line after 'match'")
+	p.expect(lexer.TokenIndent, "expected an indented block of match branches")
+
+	for !p.check(lexer.TokenDedent) && !p.isAtEnd() {
+		if p.match(lexer.TokenNewline) {
+			continue
+		}
+
+		p.recoverable(func() {
+			stmt.Branches = append(stmt.Branches, p.parseMatchBranch())
+		})
+	}
+
+	p.match(lexer.TokenDedent)
+	stmt.node = p.nodeFrom(start)
+
+	return stmt
+}
+
+func (p *Parser) parseMatchBranch() *MatchBranch {
+	start := p.peek()
+	branch := &MatchBranch{Patterns: []Pattern{p.parsePattern()}}
+
+	for p.match(lexer.TokenComma) {
+		branch.Patterns = append(branch.Patterns, p.parsePattern())
+	}
+
+	if p.match(lexer.TokenWhen) {
+		branch.Guard = p.parseExpression()
+	}
+
+	branch.Body = p.parseBlock()
+	branch.node = p.nodeFrom(start)
+
+	return branch
+}
+
+func (p *Parser) parsePattern() Pattern {
+	start := p.peek()
+
+	switch start.Type {
+	case lexer.TokenVar:
+		p.advance()
+		name := p.expectIdentifier("expected a binding name after 'var'")
+		return &BindingPattern{node: p.nodeFrom(start), Name: name}
+	case lexer.TokenIdentifier:
+		if start.Value == "_" {
+			p.advance()
+			return &WildcardPattern{node: p.nodeFrom(start)}
+		}
+	case lexer.TokenPeriodPeriod:
+		p.advance()
+		return &RestPattern{node: p.nodeFrom(start)}
+	case lexer.TokenLBracket:
+		p.advance()
+		pattern := &ArrayPattern{Elements: make([]Pattern, 0)}
+
+		for !p.check(lexer.TokenRBracket) {
+			pattern.Elements = append(pattern.Elements, p.parsePattern())
+			if !p.match(lexer.TokenComma) {
+				break
+			}
+		}
+
+		p.expect(lexer.TokenRBracket, "expected ']' after array pattern")
+		pattern.node = p.nodeFrom(start)
+		return pattern
+	case lexer.TokenLBrace:
+		p.advance()
+		pattern := &DictionaryPattern{Entries: make([]*DictionaryPatternEntry, 0)}
+
+		for !p.check(lexer.TokenRBrace) {
+			entryStart := p.peek()
+			entry := &DictionaryPatternEntry{}
+
+			if entryStart.Type == lexer.TokenPeriodPeriod {
+				entry.Value = p.parsePattern()
+			} else {
+				entry.Key = p.parseExpression()
+				if p.match(lexer.TokenColon) {
+					entry.Value = p.parsePattern()
+				}
+			}
+
+			entry.node = p.nodeFrom(entryStart)
+			pattern.Entries = append(pattern.Entries, entry)
+
+			if !p.match(lexer.TokenComma) {
+				break
+			}
+		}
+
+		p.expect(lexer.TokenRBrace, "expected '}' after dictionary pattern")
+		pattern.node = p.nodeFrom(start)
+		return pattern
+	}
+
+	expression := p.parseExpression()
+	return &ExpressionPattern{node: p.nodeFrom(start), Expression: expression}
+}
@@ -1,129 +1,233 @@
 package analysis
 
 import (
-	"bufio"
-	"bytes"
-	"fmt"
+	"strconv"
 	"strings"
+
+	"gdx/analysis/variant"
 )
 
 type InputConfig struct {
-	Name       string
-	Deadzone   float32
+	Name     string
+	Deadzone float32
+	// the names of the events joined with commas, such as "W, Joypad Axis 1 -"
 	Keybinding string
+	Events     []InputEvent
+}
+
+// a script or scene loaded when the game starts, set in the [autoload] section
+type Autoload struct {
+	Name string
+	Path string
+	// autoloads written with a * before the path are also global variables named after the autoload
+	Enabled bool
+}
+
+// a name given to a layer in the [layer_names] section
+type LayerName struct {
+	// the kind of layer such as 2d_physics, 3d_render or avoidance
+	Kind string
+	// layers are numbered from 1
+	Layer int
+	Name  string
+}
+
+// a group listed in the [global_group] section
+type GlobalGroup struct {
+	Name        string
+	Description string
 }
 
 type GodotProjectFile struct {
+	// the whole file, for settings that don't have a field
+	Config          *ConfigFile
+	ConfigVersion   int64
 	ApplicationName string
-	InputConfigs    []InputConfig
+	// a res:// path or uid:// for the scene run first
+	MainScene string
+	// feature tags such as the Godot version and renderer, "4.4" and "GL Compatibility"
+	Features       []string
+	Autoloads      []Autoload
+	LayerNames     []LayerName
+	EnabledPlugins []string
+	Translations   []string
+	GlobalGroups   []GlobalGroup
+	InputConfigs   []InputConfig
 }
 
-type IniData map[string]map[string]string
+// finds the value of a setting by its path as used by ProjectSettings, such as
+// "display/window/size/viewport_width". The section is the part before the first slash
+func (p *GodotProjectFile) Setting(path string) (any, bool) {
+	if p.Config == nil {
+		return nil, false
+	}
 
-func parseIniFile(contents []byte) (IniData, error) {
-	data := make(IniData)
-	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	section, key, ok := strings.Cut(path, "/")
+	if !ok {
+		return p.Config.Get("", path)
+	}
 
-	// Default section for entries before the first named section
-	currentSection := "default"
-	data[currentSection] = make(map[string]string)
+	return p.Config.Get(section, key)
+}
 
-	lineNum := 0
-	var inMultilineValue bool
-	var currentKey string
-	var multilineValue strings.Builder
+// finds an autoload by name
+func (p *GodotProjectFile) Autoload(name string) (Autoload, bool) {
+	for _, autoload := range p.Autoloads {
+		if autoload.Name == name {
+			return autoload, true
+		}
+	}
 
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
+	return Autoload{}, false
+}
 
-		if line == "" && !inMultilineValue {
-			continue
+// finds the name of a layer, kind is the part of the key before the slash such as 2d_physics
+func (p *GodotProjectFile) LayerName(kind string, layer int) (string, bool) {
+	for _, name := range p.LayerNames {
+		if name.Kind == kind && name.Layer == layer {
+			return name.Name, true
 		}
+	}
 
-		if strings.HasPrefix(line, ";") && !inMultilineValue {
-			continue
-		}
+	return "", false
+}
 
-		if inMultilineValue {
-			// Check if this line ends the multiline value
-			if strings.HasSuffix(line, "}") {
-				multilineValue.WriteString(line[:len(line)-1])
-				data[currentSection][currentKey] = multilineValue.String()
-				inMultilineValue = false
-				multilineValue.Reset()
-			} else {
-				// Add this line to the multiline value
-				multilineValue.WriteString(line)
-				multilineValue.WriteString("\n")
-			}
-			continue
+// finds an input action by name
+func (p *GodotProjectFile) InputConfig(name string) (InputConfig, bool) {
+	for _, input := range p.InputConfigs {
+		if input.Name == name {
+			return input, true
 		}
+	}
 
-		// Check for section
-		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
-			// Extract section name without brackets
-			currentSection = line[1 : len(line)-1]
-			// Initialize the section if it doesn't exist
-			if _, exists := data[currentSection]; !exists {
-				data[currentSection] = make(map[string]string)
-			}
+	return InputConfig{}, false
+}
+
+// reads the project from project.godot. Like ParseConfigFile, syntax errors don't stop parsing,
+// the project is built from the keys that could be parsed and the errors are returned with it
+func ParseGodotProjectFile(contents []byte) (*GodotProjectFile, []*variant.SyntaxError) {
+	var projectData GodotProjectFile
+
+	config, errors := ParseConfigFile(contents)
+
+	projectData.Config = config
+	projectData.ConfigVersion, _ = ConfigValue[int64](config, "", "config_version")
+	projectData.ApplicationName, _ = ConfigValue[string](config, "application", "config/name")
+	projectData.MainScene, _ = ConfigValue[string](config, "application", "run/main_scene")
+
+	features, _ := config.Get("application", "config/features")
+	projectData.Features = stringList(features)
+
+	plugins, _ := config.Get("editor_plugins", "enabled")
+	projectData.EnabledPlugins = stringList(plugins)
+
+	translations, _ := config.Get("internationalization", "locale/translations")
+	projectData.Translations = stringList(translations)
+
+	for _, key := range sectionKeys(config, "autoload") {
+		path, _ := key.Value.(string)
+		projectData.Autoloads = append(projectData.Autoloads, Autoload{
+			Name:    key.Name,
+			Path:    strings.TrimPrefix(path, "*"),
+			Enabled: strings.HasPrefix(path, "*"),
+		})
+	}
+
+	for _, key := range sectionKeys(config, "layer_names") {
+		kind, layer, ok := strings.Cut(key.Name, "/layer_")
+		number, err := strconv.Atoi(layer)
+		name, isString := key.Value.(string)
+		if !ok || err != nil || !isString {
 			continue
 		}
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid format at line %d: %s", lineNum, line)
-		}
+		projectData.LayerNames = append(projectData.LayerNames, LayerName{Kind: kind, Layer: number, Name: name})
+	}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
-
-		// Check if this is the start of a multiline value
-		if strings.HasPrefix(value, "{") {
-			if strings.HasSuffix(value, "}") {
-				// Single line with curly braces, just strip them
-				data[currentSection][key] = value[1 : len(value)-1]
-			} else {
-				// Start of multiline value
-				inMultilineValue = true
-				currentKey = key
-				multilineValue.WriteString(value[1:])
-				multilineValue.WriteString("\n")
-			}
-		} else {
-			// Regular key-value pair
-			data[currentSection][key] = value
-		}
+	for _, key := range sectionKeys(config, "global_group") {
+		description, _ := key.Value.(string)
+		projectData.GlobalGroups = append(projectData.GlobalGroups, GlobalGroup{Name: key.Name, Description: description})
+	}
+
+	for _, key := range sectionKeys(config, "input") {
+		projectData.InputConfigs = append(projectData.InputConfigs, parseInputConfig(key))
 	}
 
-	// Check if we ended in the middle of a multiline value
-	if inMultilineValue {
-		return nil, fmt.Errorf("unclosed multiline value starting at key %s", currentKey)
+	return &projectData, errors
+}
+
+// decodes an action in the input section, which is saved as a dictionary with a deadzone and a list of events
+func parseInputConfig(key *ConfigKey) InputConfig {
+	input := InputConfig{Name: key.Name}
+
+	action, ok := key.Value.(variant.Dictionary)
+	if !ok {
+		return input
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if deadzone, ok := action.Get("deadzone"); ok {
+		input.Deadzone = float32(toFloat(deadzone))
 	}
 
-	return data, nil
+	events, _ := action.Get("events")
+	array, _ := events.(variant.Array)
+
+	names := make([]string, 0, len(array))
+	for _, element := range array {
+		object, ok := element.(variant.Object)
+		if !ok {
+			continue
+		}
+
+		if event, ok := decodeInputEvent(object); ok {
+			input.Events = append(input.Events, event)
+			names = append(names, event.String())
+		}
+	}
+
+	input.Keybinding = strings.Join(names, ", ")
+
+	return input
 }
 
-func ParseGodotProjectFile(contents []byte) (*GodotProjectFile, error) {
-	var projectData GodotProjectFile
+// returns the keys of every section with the name, a key set more than once is only returned the last time
+func sectionKeys(config *ConfigFile, name string) []*ConfigKey {
+	keys := make([]*ConfigKey, 0)
+	seen := make(map[string]int)
+
+	for _, section := range config.Sections {
+		if section.Name != name {
+			continue
+		}
 
-	iniData, err := parseIniFile(contents)
-	if err != nil {
-		return nil, err
+		for _, key := range section.Keys {
+			if index, ok := seen[key.Name]; ok {
+				keys[index] = key
+				continue
+			}
+
+			seen[key.Name] = len(keys)
+			keys = append(keys, key)
+		}
 	}
 
-	projectData.ApplicationName = iniData["application"]["config/name"]
+	return keys
+}
 
-	for key := range iniData["input"] {
-		projectData.InputConfigs = append(projectData.InputConfigs, InputConfig{
-			Name: key,
-		})
+// converts a PackedStringArray or an array of strings to a slice
+func stringList(value any) []string {
+	switch value := value.(type) {
+	case variant.PackedStringArray:
+		return []string(value)
+	case variant.Array:
+		result := make([]string, 0, len(value))
+		for _, element := range value {
+			if text, ok := element.(string); ok {
+				result = append(result, text)
+			}
+		}
+		return result
 	}
 
-	return &projectData, nil
+	return nil
 }
@@ -2,7 +2,9 @@ package analysis_test
 
 import (
 	"gdx/analysis"
+	"gdx/analysis/variant"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -39,24 +41,270 @@ back={
 
 	expectedInputs := []analysis.InputConfig{
 		{
-			Name: "forward",
+			Name:       "forward",
+			Deadzone:   0.2,
+			Keybinding: "W",
+			Events:     []analysis.InputEvent{analysis.InputEventKey{PhysicalKeycode: 87, Unicode: 119}},
 		},
 		{
-			Name: "back",
+			Name:       "back",
+			Deadzone:   0.2,
+			Keybinding: "S",
+			Events:     []analysis.InputEvent{analysis.InputEventKey{PhysicalKeycode: 83, Unicode: 115}},
 		},
 	}
 
-	projectConfig, err := analysis.ParseGodotProjectFile([]byte(example))
-	if err != nil {
-		t.Errorf("error while parsing: %s\n", err)
+	projectConfig, errors := analysis.ParseGodotProjectFile([]byte(example))
+	if len(errors) > 0 {
+		t.Errorf("error while parsing: %s\n", errors[0])
 	}
 
-	if projectConfig.ApplicationName != `"Strategy Game"` {
-		t.Errorf("expected '%s', got '%s'\n", `"Strategy Game"`, projectConfig.ApplicationName)
+	if projectConfig.ApplicationName != "Strategy Game" {
+		t.Errorf("expected '%s', got '%s'\n", "Strategy Game", projectConfig.ApplicationName)
 	}
 
 	if !reflect.DeepEqual(projectConfig.InputConfigs, expectedInputs) {
 		t.Errorf("expected '%+v', got '%+v'\n", expectedInputs, projectConfig.InputConfigs)
 	}
+}
+
+func TestGodotProjectFile(t *testing.T) {
+	example := `config_version=5
+
+[application]
+
+config/name="Platformer"
+run/main_scene="res://scenes/main.tscn"
+config/features=PackedStringArray("4.4", "Forward Plus")
+
+[autoload]
+
+Globals="*res://globals.gd"
+Music="res://music/music.tscn"
+
+[display]
+
+window/size/viewport_width=1920
+window/stretch/scale=2
+
+[editor_plugins]
+
+enabled=PackedStringArray("res://addons/dialogue/plugin.cfg")
+
+[global_group]
+
+enemies="Things that hurt the player"
+
+[internationalization]
+
+locale/translations=PackedStringArray("res://text/en.po", "res://text/fr.po")
+
+[layer_names]
+
+2d_physics/layer_1="World"
+2d_physics/layer_3="Enemies"
+3d_render/layer_2="Effects"
+`
+
+	projectConfig, errors := analysis.ParseGodotProjectFile([]byte(example))
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error: %v", errors[0])
+	}
+
+	actual := *projectConfig
+	actual.Config = nil
+
+	expected := analysis.GodotProjectFile{
+		ConfigVersion:   5,
+		ApplicationName: "Platformer",
+		MainScene:       "res://scenes/main.tscn",
+		Features:        []string{"4.4", "Forward Plus"},
+		Autoloads: []analysis.Autoload{
+			{Name: "Globals", Path: "res://globals.gd", Enabled: true},
+			{Name: "Music", Path: "res://music/music.tscn", Enabled: false},
+		},
+		LayerNames: []analysis.LayerName{
+			{Kind: "2d_physics", Layer: 1, Name: "World"},
+			{Kind: "2d_physics", Layer: 3, Name: "Enemies"},
+			{Kind: "3d_render", Layer: 2, Name: "Effects"},
+		},
+		EnabledPlugins: []string{"res://addons/dialogue/plugin.cfg"},
+		Translations:   []string{"res://text/en.po", "res://text/fr.po"},
+		GlobalGroups:   []analysis.GlobalGroup{{Name: "enemies", Description: "Things that hurt the player"}},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	if name, ok := projectConfig.LayerName("2d_physics", 3); !ok || name != "Enemies" {
+		t.Errorf("expected layer 3 to be named Enemies, got %q", name)
+	}
+
+	if width, ok := projectConfig.Setting("display/window/size/viewport_width"); !ok || width != int64(1920) {
+		t.Errorf("expected a viewport width of 1920, got %v", width)
+	}
+
+	if scale, ok := analysis.ConfigValue[float64](projectConfig.Config, "display", "window/stretch/scale"); !ok || scale != 2 {
+		t.Errorf("expected a scale of 2, got %v", scale)
+	}
 
+	if _, ok := analysis.ConfigValue[string](projectConfig.Config, "display", "window/stretch/scale"); ok {
+		t.Error("expected the scale not to be a string")
+	}
+}
+
+func TestGodotProjectFileWithSyntaxErrors(t *testing.T) {
+	example := `config_version=5
+
+[application]
+
+config/name="Platformer
+config/features=PackedStringArray("4.4"
+
+[input]
+
+jump={
+"deadzone": 0.5,
+"events": []
+}
+`
+
+	projectConfig, errors := analysis.ParseGodotProjectFile([]byte(example))
+	if len(errors) == 0 {
+		t.Fatal("expected syntax errors")
+	}
+
+	if _, ok := projectConfig.InputConfig("jump"); !ok || projectConfig.ConfigVersion != 5 {
+		t.Errorf("expected the keys after the errors to be read, got %+v", projectConfig)
+	}
+}
+
+func TestInputEventNames(t *testing.T) {
+	tests := []struct {
+		event    string
+		expected string
+	}{
+		{event: `Object(InputEventKey,"ctrl_pressed":true,"keycode":87)`, expected: "Ctrl+W"},
+		{event: `Object(InputEventKey,"shift_pressed":true,"alt_pressed":true,"physical_keycode":4194332)`, expected: "Shift+Alt+F1"},
+		{event: `Object(InputEventKey,"command_or_control_autoremap":true,"keycode":83)`, expected: "Command/Ctrl+S"},
+		{event: `Object(InputEventKey,"keycode":32)`, expected: "Space"},
+		{event: `Object(InputEventKey,"keycode":4194320)`, expected: "Up"},
+		{event: `Object(InputEventKey,"keycode":4194439)`, expected: "Kp 1"},
+		{event: `Object(InputEventKey,"keycode":0,"unicode":233)`, expected: "É"},
+		{event: `Object(InputEventMouseButton,"button_index":1)`, expected: "Left Mouse Button"},
+		{event: `Object(InputEventMouseButton,"ctrl_pressed":true,"button_index":4)`, expected: "Ctrl+Mouse Wheel Up"},
+		{event: `Object(InputEventJoypadButton,"button_index":0)`, expected: "Joypad Button 0"},
+		{event: `Object(InputEventJoypadMotion,"axis":1,"axis_value":-1.0)`, expected: "Joypad Axis 1 -"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			contents := "[input]\naction={\n\"deadzone\": 0.5,\n\"events\": [" + test.event + "]\n}\n"
+
+			projectConfig, errors := analysis.ParseGodotProjectFile([]byte(contents))
+			if len(errors) > 0 {
+				t.Fatalf("unexpected error: %v", errors[0])
+			}
+
+			action, ok := projectConfig.InputConfig("action")
+			if !ok || len(action.Events) != 1 {
+				t.Fatalf("expected one event, got %+v", projectConfig.InputConfigs)
+			}
+
+			if action.Events[0].String() != test.expected || action.Keybinding != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, action.Events[0].String())
+			}
+
+			if action.Deadzone != 0.5 {
+				t.Errorf("expected a deadzone of 0.5, got %v", action.Deadzone)
+			}
+		})
+	}
+}
+
+func TestParseConfigFile(t *testing.T) {
+	example := `config_version=5
+
+[application]
+
+config/name="Game"
+config/features=PackedStringArray("4.4", "GL Compatibility")
+
+[display]
+
+window/size/viewport_width=1152
+window/stretch/scale=1.5
+
+[application]
+
+config/name="Renamed"
+`
+
+	config, errors := analysis.ParseConfigFile([]byte(example))
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors: %v", errors)
+	}
+
+	sections := make([]string, 0)
+	for _, section := range config.Sections {
+		keys := make([]string, 0)
+		for _, key := range section.Keys {
+			keys = append(keys, key.Name)
+		}
+		sections = append(sections, section.Name+" "+strings.Join(keys, ","))
+	}
+
+	expectedSections := []string{
+		" config_version",
+		"application config/name,config/features",
+		"display window/size/viewport_width,window/stretch/scale",
+		"application config/name",
+	}
+
+	if !reflect.DeepEqual(sections, expectedSections) {
+		t.Errorf("expected sections %q, got %q", expectedSections, sections)
+	}
+
+	values := []struct {
+		section  string
+		key      string
+		expected any
+	}{
+		{section: "", key: "config_version", expected: int64(5)},
+		{section: "application", key: "config/name", expected: "Renamed"},
+		{section: "application", key: "config/features", expected: variant.PackedStringArray{"4.4", "GL Compatibility"}},
+		{section: "display", key: "window/size/viewport_width", expected: int64(1152)},
+		{section: "display", key: "window/stretch/scale", expected: 1.5},
+	}
+
+	for _, value := range values {
+		actual, ok := config.Get(value.section, value.key)
+		if !ok || !reflect.DeepEqual(actual, value.expected) {
+			t.Errorf("expected %s/%s to be %#v, got %#v", value.section, value.key, value.expected, actual)
+		}
+	}
+}
+
+func TestUnknownProjectSetting(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{path: "application/config/name", expected: false},
+		{path: "application/config/nmae", expected: true},
+		{path: "application/run/max_fps.mobile", expected: false},
+		{path: "layer_names/2d_physics/layer_32", expected: false},
+		{path: "layer_names/2d_physics/layer_33", expected: true},
+		{path: "rendering/lights_and_shadows/directional_shadow/size", expected: false},
+		{path: "config_version", expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			if actual := analysis.UnknownProjectSetting(test.path); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
 }
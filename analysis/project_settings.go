@@ -0,0 +1,264 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// a setting Godot reads from project.godot
+type ProjectSetting struct {
+	// the path used by ProjectSettings, such as application/config/name
+	Path string
+	Type string
+	// the default value written the way it would be in project.godot
+	Default     string
+	Description string
+}
+
+// the sections Godot writes to project.godot
+var ProjectSections = []string{
+	"animation",
+	"application",
+	"audio",
+	"autoload",
+	"debug",
+	"display",
+	"dotnet",
+	"editor",
+	"editor_plugins",
+	"filesystem",
+	"global_group",
+	"gui",
+	"input",
+	"input_devices",
+	"internationalization",
+	"layer_names",
+	"memory",
+	"navigation",
+	"network",
+	"physics",
+	"rendering",
+	"shader_globals",
+	"threading",
+	"xr",
+}
+
+// the settings gdx knows about. Godot has far more than this so only the groups in
+// checkedSettingGroups list every setting they have
+var ProjectSettings = append([]ProjectSetting{
+	{"config_version", "int", "5", "The version of the project.godot format, 5 for Godot 4."},
+
+	{"application/config/name", "String", `""`, "The project's name, shown in the project manager and as the window title."},
+	{"application/config/name_localized", "Dictionary", "{}", "Translations of the project's name, keyed by locale."},
+	{"application/config/description", "String", `""`, "The project's description, shown in the project manager."},
+	{"application/config/version", "String", `""`, "The project's version, used when exporting."},
+	{"application/config/tags", "PackedStringArray", "PackedStringArray()", "Tags used to filter projects in the project manager."},
+	{"application/config/icon", "String", `""`, "The icon of the project, used by the project manager and for the window."},
+	{"application/config/macos_native_icon", "String", `""`, "An .icns file used as the application icon on macOS."},
+	{"application/config/windows_native_icon", "String", `""`, "An .ico file used as the application icon on Windows."},
+	{"application/config/use_custom_user_dir", "bool", "false", "Stores user:// data in custom_user_dir_name instead of a folder named after the project."},
+	{"application/config/custom_user_dir_name", "String", `""`, "The name of the user:// data directory when use_custom_user_dir is enabled."},
+	{"application/config/project_settings_override", "String", `""`, "A file loaded to override project settings when the game starts."},
+	{"application/config/features", "PackedStringArray", "PackedStringArray()", "The feature tags of the project such as the Godot version and renderer. Set by the editor."},
+	{"application/config/auto_accept_quit", "bool", "true", "Quits when the window is closed without waiting for the game to call quit."},
+	{"application/config/quit_on_go_back", "bool", "true", "Quits when the back button is pressed on Android."},
+	{"application/config/use_hidden_project_data_directory", "bool", "true", "Stores imported files in .godot instead of godot."},
+
+	{"application/run/main_scene", "String", `""`, "The scene loaded when the project runs."},
+	{"application/run/disable_stdout", "bool", "false", "Disables printing to standard output."},
+	{"application/run/disable_stderr", "bool", "false", "Disables printing errors to standard error."},
+	{"application/run/flush_stdout_on_print", "bool", "false", "Flushes standard output after every print."},
+	{"application/run/print_header", "bool", "true", "Prints the engine version and renderer when starting."},
+	{"application/run/enable_alt_space_menu", "bool", "false", "Opens the window menu with Alt+Space on Windows."},
+	{"application/run/main_loop_type", "String", `"SceneTree"`, "The class used as the main loop."},
+	{"application/run/low_processor_mode", "bool", "false", "Only redraws when something changes, to save power."},
+	{"application/run/low_processor_mode_sleep_usec", "int", "6900", "How long to sleep between frames in low processor mode."},
+	{"application/run/delta_smoothing", "bool", "true", "Smooths out frame timings when vsync is enabled."},
+	{"application/run/max_fps", "int", "0", "Limits the frame rate, 0 means no limit."},
+	{"application/run/frame_delay_msec", "int", "0", "Waits this long between frames, for simulating slow devices."},
+
+	{"application/boot_splash/bg_color", "Color", "Color(0.14, 0.14, 0.14, 1)", "The background color of the boot splash."},
+	{"application/boot_splash/show_image", "bool", "true", "Shows an image on the boot splash."},
+	{"application/boot_splash/image", "String", `""`, "The image shown on the boot splash, the Godot logo if empty."},
+	{"application/boot_splash/fullsize", "bool", "true", "Scales the boot splash image to the size of the window."},
+	{"application/boot_splash/stretch_mode", "int", "1", "How the boot splash image is scaled to the window."},
+	{"application/boot_splash/use_filter", "bool", "true", "Filters the boot splash image when it's scaled."},
+	{"application/boot_splash/minimum_display_time", "int", "0", "The minimum time the boot splash is shown in milliseconds."},
+
+	{"audio/buses/default_bus_layout", "String", `"res://default_bus_layout.tres"`, "The audio bus layout loaded when the game starts."},
+	{"audio/driver/driver", "String", `""`, "The audio driver to use."},
+	{"audio/driver/enable_input", "bool", "false", "Enables audio input such as microphones."},
+	{"audio/driver/mix_rate", "int", "44100", "The audio mix rate in Hz."},
+	{"audio/driver/output_latency", "int", "15", "The output latency in milliseconds."},
+
+	{"display/window/size/viewport_width", "int", "1152", "The width of the game's viewport, and of the window unless overridden."},
+	{"display/window/size/viewport_height", "int", "648", "The height of the game's viewport, and of the window unless overridden."},
+	{"display/window/size/mode", "int", "0", "The window mode, 0 is windowed, 2 is maximized, 3 is fullscreen and 4 is exclusive fullscreen."},
+	{"display/window/size/initial_position_type", "int", "1", "Where the window is placed when the game starts."},
+	{"display/window/size/initial_position", "Vector2i", "Vector2i(0, 0)", "The position of the window when initial_position_type is absolute."},
+	{"display/window/size/initial_screen", "int", "0", "The screen the window opens on."},
+	{"display/window/size/resizable", "bool", "true", "Allows the window to be resized."},
+	{"display/window/size/borderless", "bool", "false", "Removes the window decorations."},
+	{"display/window/size/always_on_top", "bool", "false", "Keeps the window above other windows."},
+	{"display/window/size/transparent", "bool", "false", "Makes the window background transparent."},
+	{"display/window/size/extend_to_title", "bool", "false", "Draws the game under the title bar on macOS."},
+	{"display/window/size/no_focus", "bool", "false", "Stops the window from taking focus."},
+	{"display/window/size/sharp_corners", "bool", "false", "Disables rounded window corners on Windows 11."},
+	{"display/window/size/minimize_disabled", "bool", "false", "Disables the minimize button."},
+	{"display/window/size/maximize_disabled", "bool", "false", "Disables the maximize button."},
+	{"display/window/size/window_width_override", "int", "0", "The width of the window when it isn't the viewport width, 0 to use the viewport width."},
+	{"display/window/size/window_height_override", "int", "0", "The height of the window when it isn't the viewport height, 0 to use the viewport height."},
+
+	{"display/window/stretch/mode", "String", `"disabled"`, "How the game is scaled to the window: disabled, canvas_items or viewport."},
+	{"display/window/stretch/aspect", "String", `"keep"`, "How the aspect ratio is kept when scaling: ignore, keep, keep_width, keep_height or expand."},
+	{"display/window/stretch/scale", "float", "1.0", "Scales the game on top of the stretch mode."},
+	{"display/window/stretch/scale_mode", "String", `"fractional"`, "Whether the scale is fractional or rounded down to an integer."},
+
+	{"display/window/vsync/vsync_mode", "int", "1", "The vsync mode, 0 is disabled, 1 enabled, 2 adaptive and 3 mailbox."},
+	{"display/window/energy_saving/keep_screen_on", "bool", "true", "Stops the screen from turning off while the game runs."},
+	{"display/window/subwindows/embed_subwindows", "bool", "true", "Draws subwindows such as popups inside the main window."},
+	{"display/window/per_pixel_transparency/allowed", "bool", "false", "Allows the window background to be transparent."},
+	{"display/window/handheld/orientation", "int", "0", "The screen orientation on mobile devices."},
+	{"display/window/dpi/allow_hidpi", "bool", "true", "Renders at the native resolution on high DPI screens."},
+
+	{"display/mouse_cursor/custom_image", "String", `""`, "An image used as the mouse cursor."},
+	{"display/mouse_cursor/custom_image_hotspot", "Vector2", "Vector2(0, 0)", "The point in the custom cursor image that clicks."},
+	{"display/mouse_cursor/tooltip_position_offset", "Vector2", "Vector2(10, 10)", "The offset of tooltips from the mouse cursor."},
+
+	{"editor_plugins/enabled", "PackedStringArray", "PackedStringArray()", "The plugin.cfg files of the enabled editor plugins."},
+
+	{"gui/theme/custom", "String", `""`, "A theme used by every control in the project."},
+	{"gui/theme/custom_font", "String", `""`, "A font used by every control in the project."},
+	{"gui/common/snap_controls_to_pixels", "bool", "true", "Rounds the positions of controls to whole pixels."},
+
+	{"input_devices/pointing/emulate_touch_from_mouse", "bool", "false", "Sends touch events when the mouse is used."},
+	{"input_devices/pointing/emulate_mouse_from_touch", "bool", "true", "Sends mouse events when the screen is touched."},
+
+	{"internationalization/locale/translations", "PackedStringArray", "PackedStringArray()", "The translation files loaded when the game starts."},
+	{"internationalization/locale/fallback", "String", `"en"`, "The locale used when there is no translation for the current one."},
+	{"internationalization/locale/test", "String", `""`, "A locale used instead of the system's, for testing translations."},
+
+	{"physics/common/physics_ticks_per_second", "int", "60", "How many times physics is processed each second."},
+	{"physics/common/max_physics_steps_per_frame", "int", "8", "The most physics steps run in one frame when the game falls behind."},
+	{"physics/common/physics_jitter_fix", "float", "0.5", "Reduces jitter when the frame rate doesn't match the physics rate."},
+	{"physics/common/physics_interpolation", "bool", "false", "Interpolates physics objects between physics steps."},
+
+	{"physics/2d/physics_engine", "String", `"DEFAULT"`, "The 2D physics engine."},
+	{"physics/2d/default_gravity", "float", "980.0", "The strength of gravity in 2D, in pixels per second squared."},
+	{"physics/2d/default_gravity_vector", "Vector2", "Vector2(0, 1)", "The direction of gravity in 2D."},
+	{"physics/2d/default_linear_damp", "float", "0.1", "How quickly 2D bodies stop moving."},
+	{"physics/2d/default_angular_damp", "float", "1.0", "How quickly 2D bodies stop rotating."},
+	{"physics/2d/run_on_separate_thread", "bool", "false", "Processes 2D physics on its own thread."},
+
+	{"physics/3d/physics_engine", "String", `"DEFAULT"`, "The 3D physics engine."},
+	{"physics/3d/default_gravity", "float", "9.8", "The strength of gravity in 3D, in meters per second squared."},
+	{"physics/3d/default_gravity_vector", "Vector3", "Vector3(0, -1, 0)", "The direction of gravity in 3D."},
+	{"physics/3d/default_linear_damp", "float", "0.1", "How quickly 3D bodies stop moving."},
+	{"physics/3d/default_angular_damp", "float", "0.1", "How quickly 3D bodies stop rotating."},
+	{"physics/3d/run_on_separate_thread", "bool", "false", "Processes 3D physics on its own thread."},
+
+	{"rendering/renderer/rendering_method", "String", `"forward_plus"`, "The renderer: forward_plus, mobile or gl_compatibility."},
+	{"rendering/textures/canvas_textures/default_texture_filter", "int", "1", "The texture filter used by 2D nodes that inherit it."},
+	{"rendering/textures/canvas_textures/default_texture_repeat", "int", "0", "The texture repeat mode used by 2D nodes that inherit it."},
+	{"rendering/environment/defaults/default_clear_color", "Color", "Color(0.3, 0.3, 0.3, 1)", "The background color when nothing else is drawn."},
+	{"rendering/anti_aliasing/quality/msaa_2d", "int", "0", "Multisample anti-aliasing for 2D."},
+	{"rendering/anti_aliasing/quality/msaa_3d", "int", "0", "Multisample anti-aliasing for 3D."},
+	{"rendering/anti_aliasing/quality/screen_space_aa", "int", "0", "Screen space anti-aliasing, 1 is FXAA and 2 is SMAA."},
+	{"rendering/anti_aliasing/quality/use_taa", "bool", "false", "Enables temporal anti-aliasing."},
+	{"rendering/anti_aliasing/quality/use_debanding", "bool", "false", "Removes banding from gradients."},
+	{"rendering/2d/snap/snap_2d_transforms_to_pixel", "bool", "false", "Rounds the positions of 2D nodes to whole pixels."},
+	{"rendering/2d/snap/snap_2d_vertices_to_pixel", "bool", "false", "Rounds the vertices of 2D meshes to whole pixels."},
+}, layerNameSettings()...)
+
+// groups of settings where every setting is listed above, so any other key in them is a mistake
+var checkedSettingGroups = map[string]bool{
+	"application/config":                    true,
+	"application/run":                       true,
+	"application/boot_splash":               true,
+	"display/window/size":                   true,
+	"display/window/stretch":                true,
+	"display/window/vsync":                  true,
+	"display/window/energy_saving":          true,
+	"display/window/subwindows":             true,
+	"display/mouse_cursor":                  true,
+	"editor_plugins":                        true,
+	"physics/common":                        true,
+	"rendering/textures/canvas_textures":    true,
+	"layer_names/2d_render":                 true,
+	"layer_names/2d_physics":                true,
+	"layer_names/2d_navigation":             true,
+	"layer_names/3d_render":                 true,
+	"layer_names/3d_physics":                true,
+	"layer_names/3d_navigation":             true,
+	"layer_names/avoidance":                 true,
+	"display/window/per_pixel_transparency": true,
+	"display/window/dpi":                    true,
+	"rendering/2d/snap":                     true,
+}
+
+// the names of render, physics, navigation and avoidance layers
+func layerNameSettings() []ProjectSetting {
+	kinds := []struct {
+		name   string
+		layers int
+	}{
+		{"2d_render", 20},
+		{"2d_physics", 32},
+		{"2d_navigation", 32},
+		{"3d_render", 20},
+		{"3d_physics", 32},
+		{"3d_navigation", 32},
+		{"avoidance", 32},
+	}
+
+	settings := make([]ProjectSetting, 0)
+	for _, kind := range kinds {
+		for layer := 1; layer <= kind.layers; layer++ {
+			settings = append(settings, ProjectSetting{
+				Path:        fmt.Sprintf("layer_names/%s/layer_%d", kind.name, layer),
+				Type:        "String",
+				Default:     `""`,
+				Description: fmt.Sprintf("The name of %s layer %d.", strings.ReplaceAll(kind.name, "_", " "), layer),
+			})
+		}
+	}
+
+	return settings
+}
+
+// finds a setting by its path. Settings can be overridden for a feature tag by adding it
+// to the end of the key, such as rendering_method.mobile, which is the same setting
+func LookupProjectSetting(path string) (ProjectSetting, bool) {
+	path = withoutFeatureTag(path)
+
+	for _, setting := range ProjectSettings {
+		if setting.Path == path {
+			return setting, true
+		}
+	}
+
+	return ProjectSetting{}, false
+}
+
+// reports whether a setting isn't one Godot has. Only settings in groups that gdx
+// knows every setting of can be reported, anything else could be a setting gdx doesn't know
+func UnknownProjectSetting(path string) bool {
+	path = withoutFeatureTag(path)
+
+	group := path[:max(strings.LastIndexByte(path, '/'), 0)]
+	if !checkedSettingGroups[group] {
+		return false
+	}
+
+	_, ok := LookupProjectSetting(path)
+	return !ok
+}
+
+func withoutFeatureTag(path string) string {
+	name := path[strings.LastIndexByte(path, '/')+1:]
+	if before, _, found := strings.Cut(name, "."); found {
+		return path[:len(path)-len(name)] + before
+	}
+
+	return path
+}
@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"fmt"
+
+	"gdx/analysis/variant"
+)
+
+// a resource in another file, declared with an [ext_resource] tag
+type ExternalResource struct {
+	ID   string
+	Type string
+	// a res:// path, saved alongside the UID so files still load when the UID is unknown
+	Path string
+	// empty in files saved before Godot 4
+	UID   string
+	Range variant.Range
+}
+
+// a resource saved inside the file with a [sub_resource] tag
+type EmbeddedResource struct {
+	ID         string
+	Type       string
+	Properties []*ConfigKey
+	Range      variant.Range
+}
+
+// the resources declared at the top of a scene or resource file
+type FileResources struct {
+	ExtResources []*ExternalResource
+	SubResources []*EmbeddedResource
+}
+
+func newFileResources() FileResources {
+	return FileResources{
+		ExtResources: make([]*ExternalResource, 0),
+		SubResources: make([]*EmbeddedResource, 0),
+	}
+}
+
+// adds the resource declared by an [ext_resource] or [sub_resource] tag. For a sub resource
+// the properties that follow the tag are returned so they can be added to it
+func (r *FileResources) addTag(tag *variant.Tag) *[]*ConfigKey {
+	if tag.Name == "ext_resource" {
+		resource := &ExternalResource{ID: resourceTagID(tag), Range: tag.Range}
+		resource.Type, _ = tagField[string](tag, "type")
+		resource.Path, _ = tagField[string](tag, "path")
+		resource.UID, _ = tagField[string](tag, "uid")
+		r.ExtResources = append(r.ExtResources, resource)
+
+		return nil
+	}
+
+	resource := &EmbeddedResource{ID: resourceTagID(tag), Range: tag.Range, Properties: make([]*ConfigKey, 0)}
+	resource.Type, _ = tagField[string](tag, "type")
+	r.SubResources = append(r.SubResources, resource)
+
+	return &resource.Properties
+}
+
+// finds an [ext_resource] by its id
+func (r *FileResources) ExtResource(id string) *ExternalResource {
+	for _, resource := range r.ExtResources {
+		if resource.ID == id {
+			return resource
+		}
+	}
+
+	return nil
+}
+
+// finds a [sub_resource] by its id
+func (r *FileResources) SubResource(id string) *EmbeddedResource {
+	for _, resource := range r.SubResources {
+		if resource.ID == id {
+			return resource
+		}
+	}
+
+	return nil
+}
+
+// a .tres file, which saves a single resource along with the resources it uses
+type ResourceFile struct {
+	// the built-in class of the resource, Resource for resources with a custom script
+	Type string
+	// the class_name of the resource's script, if it has one
+	ScriptClass string
+	Format      int64
+	UID         string
+	FileResources
+	// the script attached with the script property
+	Script *ExternalResource
+	// the keys in the [resource] section
+	Properties []*ConfigKey
+	// the range of the [resource] tag, empty if the file doesn't have one
+	Range variant.Range
+}
+
+// parses a resource in the .tres text format. Like ParseConfigFile, syntax errors don't stop parsing
+func ParseResourceFile(contents []byte) (*ResourceFile, []*variant.SyntaxError) {
+	entries, errors := variant.ParseFile(string(contents))
+
+	resource := &ResourceFile{
+		FileResources: newFileResources(),
+		Properties:    make([]*ConfigKey, 0),
+	}
+
+	// the keys after a tag belong to the last sub resource or the resource itself
+	var properties *[]*ConfigKey
+
+	for _, entry := range entries {
+		if entry.Assignment != nil {
+			if properties != nil {
+				*properties = append(*properties, &ConfigKey{
+					Name:       entry.Assignment.Key,
+					Value:      entry.Assignment.Value,
+					NameRange:  entry.Assignment.KeyRange,
+					ValueRange: entry.Assignment.ValueRange,
+				})
+			}
+			continue
+		}
+
+		tag := entry.Tag
+		properties = nil
+
+		switch tag.Name {
+		case "gd_resource":
+			resource.Type, _ = tagField[string](tag, "type")
+			resource.ScriptClass, _ = tagField[string](tag, "script_class")
+			resource.Format, _ = tagField[int64](tag, "format")
+			resource.UID, _ = tagField[string](tag, "uid")
+		case "ext_resource", "sub_resource":
+			properties = resource.addTag(tag)
+		case "resource":
+			resource.Range = tag.Range
+			properties = &resource.Properties
+		}
+	}
+
+	if script, ok := resource.Property("script"); ok {
+		if id, ok := script.Value.(variant.ExtResource); ok {
+			resource.Script = resource.ExtResource(id.ID)
+		}
+	}
+
+	return resource, errors
+}
+
+// finds a key in the [resource] section, when it's set more than once the last one is returned
+func (r *ResourceFile) Property(name string) (*ConfigKey, bool) {
+	var found *ConfigKey
+	for _, property := range r.Properties {
+		if property.Name == name {
+			found = property
+		}
+	}
+
+	return found, found != nil
+}
+
+// finds the value of a tag field and converts it to T
+func tagField[T any](tag *variant.Tag, name string) (T, bool) {
+	value, _ := tag.Get(name)
+	result, ok := value.(T)
+
+	return result, ok
+}
+
+// resources have string ids since Godot 4, before that they were numbers
+func resourceTagID(tag *variant.Tag) string {
+	value, _ := tag.Get("id")
+
+	switch id := value.(type) {
+	case string:
+		return id
+	case int64:
+		return fmt.Sprint(id)
+	}
+
+	return ""
+}
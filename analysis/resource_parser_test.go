@@ -0,0 +1,145 @@
+package analysis_test
+
+import (
+	"fmt"
+	"gdx/analysis"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseResourceFile(t *testing.T) {
+	example := `[gd_resource type="Resource" script_class="Weapon" load_steps=3 format=3 uid="uid://b7r3nq2c5yk1m"]
+
+[ext_resource type="Script" path="res://weapon.gd" id="1_w4p0c"]
+
+[sub_resource type="Gradient" id="Gradient_1"]
+offsets = PackedFloat32Array(0, 1)
+
+[resource]
+script = ExtResource("1_w4p0c")
+title = "Sword"
+trail = SubResource("Gradient_1")
+`
+
+	resource, errors := analysis.ParseResourceFile([]byte(example))
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error: %v", errors[0])
+	}
+
+	if resource.Type != "Resource" || resource.ScriptClass != "Weapon" || resource.Format != 3 || resource.UID != "uid://b7r3nq2c5yk1m" {
+		t.Errorf("expected the header to be read, got %+v", resource)
+	}
+
+	if resource.Script == nil || resource.Script.Path != "res://weapon.gd" {
+		t.Errorf("expected weapon.gd to be attached, got %+v", resource.Script)
+	}
+
+	names := make([]string, 0)
+	for _, property := range resource.Properties {
+		names = append(names, property.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"script", "title", "trail"}) {
+		t.Errorf("expected the properties of [resource], got %q", names)
+	}
+
+	if gradient := resource.SubResource("Gradient_1"); gradient == nil || len(gradient.Properties) != 1 {
+		t.Errorf("expected the gradient to keep its own properties, got %+v", gradient)
+	}
+}
+
+func TestLoadScriptClass(t *testing.T) {
+	class, err := analysis.LoadScriptClass(filepath.Join("..", "testdata", "project"), "res://weapon.gd", nil, os.ReadFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if class.Base != "Resource" {
+		t.Errorf("expected weapon.gd to inherit from Resource, got %q", class.Base)
+	}
+
+	properties := make([]string, 0)
+	for _, property := range class.Properties {
+		properties = append(properties, fmt.Sprintf("%s %s %t", property.Path, property.Name, property.Exported))
+	}
+
+	expected := []string{
+		"res://weapon.gd damage true",
+		"res://item.gd title true",
+		"res://item.gd price true",
+		"res://item.gd owner_count false",
+	}
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected %q, got %q", expected, properties)
+	}
+}
+
+func TestLoadScriptClassByClassName(t *testing.T) {
+	project := filepath.Join("..", "testdata", "project")
+
+	classes, err := analysis.BuildClassIndex(project)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	class, err := analysis.LoadScriptClass(project, "res://shield.gd", classes, os.ReadFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if class.Base != "Resource" {
+		t.Errorf("expected shield.gd to inherit from Resource through Item, got %q", class.Base)
+	}
+
+	properties := make([]string, 0)
+	for _, property := range class.Properties {
+		properties = append(properties, property.Path+" "+property.Name)
+	}
+
+	expected := []string{
+		"res://shield.gd armor",
+		"res://item.gd title",
+		"res://item.gd price",
+		"res://item.gd owner_count",
+	}
+	if !reflect.DeepEqual(properties, expected) {
+		t.Errorf("expected %q, got %q", expected, properties)
+	}
+
+	// without the index the class_name can't be followed
+	class, err = analysis.LoadScriptClass(project, "res://shield.gd", nil, os.ReadFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if class.Base != "Item" || len(class.Properties) != 1 {
+		t.Errorf("expected shield.gd to stop at Item, got %q with %d properties", class.Base, len(class.Properties))
+	}
+}
+
+func TestLoadScriptClassInnerClassOfClassName(t *testing.T) {
+	scripts := map[string]string{
+		filepath.Join("project", "stack.gd"): "extends Item.Stack\n\nvar size := 1\n",
+		filepath.Join("project", "item.gd"):  "class_name Item\nextends Resource\n\nclass Stack:\n\tvar count := 0\n",
+	}
+	read := func(filename string) ([]byte, error) {
+		script, ok := scripts[filename]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return []byte(script), nil
+	}
+
+	classes := analysis.NewClassIndex()
+	classes.Add("Item", "res://item.gd")
+
+	class, err := analysis.LoadScriptClass("project", "res://stack.gd", classes, read)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if class.Base != "" || len(class.Properties) != 1 {
+		t.Errorf("expected inner classes to not be followed, got %q with %d properties", class.Base, len(class.Properties))
+	}
+}
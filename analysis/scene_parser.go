@@ -0,0 +1,310 @@
+package analysis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gdx/analysis/variant"
+)
+
+type SceneNode struct {
+	Name string
+	// empty for nodes that instance a scene, they have the type of the scene's root
+	Type string
+	// the path of the node from the root, "." for the root itself
+	Path string
+	// the path of the parent as written in the scene, empty for the root
+	Parent string
+	// the scene this node instances, if any
+	Instance *ExternalResource
+	// the scene loaded from Instance, set by SceneLoader
+	InstancedScene *Scene
+	// the script attached with the script property
+	Script *ExternalResource
+	// set with unique_name_in_owner, the node can be found with %Name
+	Unique     bool
+	Groups     []string
+	Properties []*ConfigKey
+	Children   []*SceneNode
+	// the range of the [node] tag
+	Range variant.Range
+}
+
+// a signal connected in the editor
+type Connection struct {
+	Signal string
+	// the paths of the nodes the signal is connected from and to
+	From   string
+	To     string
+	Method string
+	Flags  int64
+	Range  variant.Range
+}
+
+// a .tscn file. Nodes are kept both as a tree from Root and in the order they're written
+type Scene struct {
+	Format int64
+	UID    string
+	// nil if the scene has no nodes
+	Root  *SceneNode
+	Nodes []*SceneNode
+	FileResources
+	Connections []Connection
+	// the paths of instanced scenes whose children can be edited in this scene
+	EditableInstances []string
+}
+
+// parses a scene in the .tscn text format. Like ParseConfigFile, syntax errors don't stop
+// parsing. Nodes whose parent can't be found are kept in Nodes but not added to the tree
+func ParseScene(contents []byte) (*Scene, []*variant.SyntaxError) {
+	entries, errors := variant.ParseFile(string(contents))
+
+	scene := &Scene{
+		Nodes:             make([]*SceneNode, 0),
+		FileResources:     newFileResources(),
+		Connections:       make([]Connection, 0),
+		EditableInstances: make([]string, 0),
+	}
+
+	// the keys after a tag belong to the last node or sub resource
+	var properties *[]*ConfigKey
+
+	for _, entry := range entries {
+		if entry.Assignment != nil {
+			if properties != nil {
+				*properties = append(*properties, &ConfigKey{
+					Name:       entry.Assignment.Key,
+					Value:      entry.Assignment.Value,
+					NameRange:  entry.Assignment.KeyRange,
+					ValueRange: entry.Assignment.ValueRange,
+				})
+			}
+			continue
+		}
+
+		tag := entry.Tag
+		properties = nil
+
+		switch tag.Name {
+		case "gd_scene":
+			scene.Format, _ = tagField[int64](tag, "format")
+			scene.UID, _ = tagField[string](tag, "uid")
+		case "ext_resource", "sub_resource":
+			properties = scene.addTag(tag)
+		case "node":
+			node := &SceneNode{Range: tag.Range, Properties: make([]*ConfigKey, 0), Children: make([]*SceneNode, 0)}
+			node.Name, _ = tagField[string](tag, "name")
+			node.Type, _ = tagField[string](tag, "type")
+			node.Parent, _ = tagField[string](tag, "parent")
+
+			groups, _ := tag.Get("groups")
+			node.Groups = stringList(groups)
+
+			if instance, ok := tagField[variant.ExtResource](tag, "instance"); ok {
+				node.Instance = scene.ExtResource(instance.ID)
+			}
+
+			scene.Nodes = append(scene.Nodes, node)
+			properties = &node.Properties
+		case "connection":
+			connection := Connection{Range: tag.Range}
+			connection.Signal, _ = tagField[string](tag, "signal")
+			connection.From, _ = tagField[string](tag, "from")
+			connection.To, _ = tagField[string](tag, "to")
+			connection.Method, _ = tagField[string](tag, "method")
+			connection.Flags, _ = tagField[int64](tag, "flags")
+			scene.Connections = append(scene.Connections, connection)
+		case "editable":
+			if path, ok := tagField[string](tag, "path"); ok {
+				scene.EditableInstances = append(scene.EditableInstances, path)
+			}
+		}
+	}
+
+	scene.buildTree()
+
+	return scene, errors
+}
+
+// links nodes to their parents, which are always written before their children
+func (s *Scene) buildTree() {
+	nodes := make(map[string]*SceneNode)
+
+	for _, node := range s.Nodes {
+		if script, ok := node.Property("script"); ok {
+			if resource, ok := script.(variant.ExtResource); ok {
+				node.Script = s.ExtResource(resource.ID)
+			}
+		}
+
+		if unique, ok := node.Property("unique_name_in_owner"); ok {
+			node.Unique, _ = unique.(bool)
+		}
+
+		if node.Parent == "" {
+			if s.Root == nil {
+				node.Path = "."
+				s.Root = node
+				nodes["."] = node
+			}
+			continue
+		}
+
+		parent, ok := nodes[node.Parent]
+		if !ok {
+			continue
+		}
+
+		node.Path = node.Name
+		if parent != s.Root {
+			node.Path = parent.Path + "/" + node.Name
+		}
+
+		parent.Children = append(parent.Children, node)
+		nodes[node.Path] = node
+	}
+}
+
+// finds a node by its path from the root, such as "Body/Sprite2D". Paths can go into instanced
+// scenes once they've been loaded, in the same way get_node() can
+func (s *Scene) Node(path string) *SceneNode {
+	if s.Root == nil {
+		return nil
+	}
+
+	node := s.Root
+	for _, name := range strings.Split(path, "/") {
+		if name == "." || name == "" {
+			continue
+		}
+
+		if node = node.Child(name); node == nil {
+			return nil
+		}
+	}
+
+	return node
+}
+
+// finds a node marked with unique_name_in_owner, which is what %Name refers to. Only the nodes
+// saved in this scene are searched, the nodes of an instanced scene are unique within that scene
+func (s *Scene) UniqueNode(name string) *SceneNode {
+	for _, node := range s.Nodes {
+		if node.Unique && node.Name == name {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// finds a child by name, including the children of an instanced scene's root
+func (n *SceneNode) Child(name string) *SceneNode {
+	for _, child := range n.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	if n.InstancedScene != nil && n.InstancedScene.Root != nil {
+		return n.InstancedScene.Root.Child(name)
+	}
+
+	return nil
+}
+
+// the class of the node, looking through instanced scenes to the type of their root
+func (n *SceneNode) NodeType() string {
+	if n.Type == "" && n.InstancedScene != nil && n.InstancedScene.Root != nil {
+		return n.InstancedScene.Root.NodeType()
+	}
+
+	return n.Type
+}
+
+// finds the value of a property set on the node
+func (n *SceneNode) Property(name string) (any, bool) {
+	var found *ConfigKey
+	for _, property := range n.Properties {
+		if property.Name == name {
+			found = property
+		}
+	}
+
+	if found == nil {
+		return nil, false
+	}
+
+	return found.Value, true
+}
+
+// loads scenes by their res:// path and the scenes they instance. Each scene is only parsed
+// once, so a scene instanced many times shares the same Scene
+type SceneLoader struct {
+	// the directory containing project.godot
+	ProjectPath string
+	scenes      map[string]*Scene
+	loading     map[string]bool
+}
+
+func NewSceneLoader(projectPath string) *SceneLoader {
+	return &SceneLoader{
+		ProjectPath: projectPath,
+		scenes:      make(map[string]*Scene),
+		loading:     make(map[string]bool),
+	}
+}
+
+// loads a scene and every scene it instances. An instanced scene that can't be loaded
+// leaves InstancedScene unset rather than failing the whole scene
+func (l *SceneLoader) Load(path string) (*Scene, error) {
+	if scene, ok := l.scenes[path]; ok {
+		return scene, nil
+	}
+
+	if l.loading[path] {
+		return nil, fmt.Errorf("%s instances itself", path)
+	}
+
+	filename, ok := ResourceFilename(l.ProjectPath, path)
+	if !ok {
+		return nil, fmt.Errorf("%s isn't a res:// path", path)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	scene, errors := ParseScene(data)
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, errors[0])
+	}
+
+	l.loading[path] = true
+	defer delete(l.loading, path)
+
+	for _, node := range scene.Nodes {
+		if node.Instance == nil {
+			continue
+		}
+
+		node.InstancedScene, _ = l.Load(node.Instance.Path)
+	}
+
+	l.scenes[path] = scene
+
+	return scene, nil
+}
+
+// converts a res:// path to a path on disk
+func ResourceFilename(projectPath string, path string) (string, bool) {
+	relative, ok := strings.CutPrefix(path, "res://")
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(projectPath, filepath.FromSlash(relative)), true
+}
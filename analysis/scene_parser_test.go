@@ -0,0 +1,139 @@
+package analysis_test
+
+import (
+	"gdx/analysis"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseScene(t *testing.T) {
+	example := `[gd_scene load_steps=3 format=3 uid="uid://cecaux1sm7mo0"]
+
+[ext_resource type="Script" uid="uid://b6x3ocelkgdrm" path="res://hud.gd" id="1_hud"]
+[ext_resource type="PackedScene" path="res://button.tscn" id="2_btn"]
+
+[sub_resource type="LabelSettings" id="LabelSettings_1"]
+font_size = 24
+
+[node name="HUD" type="CanvasLayer"]
+script = ExtResource("1_hud")
+
+[node name="Panel" type="PanelContainer" parent="."]
+
+[node name="Score" type="Label" parent="Panel" groups=["labels"]]
+unique_name_in_owner = true
+label_settings = SubResource("LabelSettings_1")
+
+[node name="Quit" parent="Panel" instance=ExtResource("2_btn")]
+
+[node name="Lost" type="Label" parent="Missing"]
+
+[connection signal="pressed" from="Panel/Quit" to="." method="_on_quit_pressed" flags=3]
+
+[editable path="Panel/Quit"]
+`
+
+	scene, errors := analysis.ParseScene([]byte(example))
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error: %v", errors[0])
+	}
+
+	if scene.Format != 3 || scene.UID != "uid://cecaux1sm7mo0" {
+		t.Errorf("expected format 3 with a UID, got %d and %q", scene.Format, scene.UID)
+	}
+
+	tree := make([]string, 0)
+	var walk func(node *analysis.SceneNode)
+	walk = func(node *analysis.SceneNode) {
+		tree = append(tree, node.Path+" "+node.Type)
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(scene.Root)
+
+	expectedTree := []string{". CanvasLayer", "Panel PanelContainer", "Panel/Score Label", "Panel/Quit "}
+	if !reflect.DeepEqual(tree, expectedTree) {
+		t.Errorf("expected %q, got %q", expectedTree, tree)
+	}
+
+	if len(scene.Nodes) != 5 {
+		t.Errorf("expected nodes without a parent to be kept, got %d nodes", len(scene.Nodes))
+	}
+
+	if scene.Root.Script == nil || scene.Root.Script.Path != "res://hud.gd" || scene.Root.Script.UID != "uid://b6x3ocelkgdrm" {
+		t.Errorf("expected the root to have hud.gd attached, got %+v", scene.Root.Script)
+	}
+
+	score := scene.UniqueNode("Score")
+	if score == nil || score != scene.Node("Panel/Score") || !reflect.DeepEqual(score.Groups, []string{"labels"}) {
+		t.Errorf("expected a unique Score node in the labels group, got %+v", score)
+	}
+
+	if quit := scene.Node("Panel/Quit"); quit == nil || quit.Instance == nil || quit.Instance.Path != "res://button.tscn" {
+		t.Errorf("expected Quit to instance button.tscn, got %+v", quit)
+	}
+
+	if settings := scene.SubResource("LabelSettings_1"); settings == nil || settings.Type != "LabelSettings" || len(settings.Properties) != 1 {
+		t.Errorf("expected the label settings sub resource, got %+v", settings)
+	}
+
+	expectedConnections := []analysis.Connection{
+		{Signal: "pressed", From: "Panel/Quit", To: ".", Method: "_on_quit_pressed", Flags: 3},
+	}
+	connections := scene.Connections
+	for i := range connections {
+		connections[i].Range = expectedConnections[i].Range
+	}
+	if !reflect.DeepEqual(connections, expectedConnections) {
+		t.Errorf("expected %+v, got %+v", expectedConnections, connections)
+	}
+
+	if !reflect.DeepEqual(scene.EditableInstances, []string{"Panel/Quit"}) {
+		t.Errorf("expected Panel/Quit to be editable, got %q", scene.EditableInstances)
+	}
+}
+
+func TestSceneLoader(t *testing.T) {
+	loader := analysis.NewSceneLoader(filepath.Join("..", "testdata", "project"))
+
+	level, err := loader.Load("res://level.tscn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	player := level.Node("Player")
+	if player == nil || player.InstancedScene == nil {
+		t.Fatalf("expected the player scene to be instanced, got %+v", player)
+	}
+
+	if player.NodeType() != "CharacterBody2D" {
+		t.Errorf("expected the instanced player to be a CharacterBody2D, got %q", player.NodeType())
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "Player/Sprite", expected: "Sprite2D"},
+		{path: "Player/Camera", expected: "Camera2D"},
+		{path: "Exit", expected: "Area2D"},
+	}
+
+	for _, test := range tests {
+		node := level.Node(test.path)
+		if node == nil || node.NodeType() != test.expected {
+			t.Errorf("expected %s to be a %s, got %+v", test.path, test.expected, node)
+		}
+	}
+
+	if level.Node("Player/Missing") != nil {
+		t.Error("expected no node at Player/Missing")
+	}
+
+	scene, err := loader.Load("res://player.tscn")
+	if err != nil || scene != player.InstancedScene {
+		t.Errorf("expected player.tscn to be loaded once, got %v", err)
+	}
+}
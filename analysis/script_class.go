@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gdx/analysis/lexer"
+	"gdx/analysis/parser"
+)
+
+// a variable declared in the body of a script
+type ScriptProperty struct {
+	Name string
+	// set with @export or one of the annotations like @export_range, so it's saved in scenes and resources
+	Exported bool
+	// the res:// path of the script that declares it
+	Path string
+	// the range of the property's name
+	Range parser.Range
+}
+
+// the properties of a script, including the ones it inherits from the scripts it extends
+type ScriptClass struct {
+	Path string
+	// the class the chain of extends ends with. It's a built-in class unless a script extends a
+	// class_name that isn't in the index, or an inner class. Scripts without extends are RefCounted
+	Base       string
+	Properties []ScriptProperty
+}
+
+// finds a property, the script's own properties are checked before the ones it inherits
+func (c *ScriptClass) Property(name string) (ScriptProperty, bool) {
+	for _, property := range c.Properties {
+		if property.Name == name {
+			return property, true
+		}
+	}
+
+	return ScriptProperty{}, false
+}
+
+// loads a script by its res:// path and the scripts it extends, by a res:// or relative path
+// or by a class_name found in classes, which may be nil. read is given the path on disk so
+// open documents can be used instead of the file that was saved
+func LoadScriptClass(projectPath string, path string, classes *ClassIndex, read func(filename string) ([]byte, error)) (*ScriptClass, error) {
+	class := &ScriptClass{Path: path, Properties: make([]ScriptProperty, 0)}
+	loaded := make(map[string]bool)
+
+	for {
+		if loaded[path] {
+			return nil, fmt.Errorf("%s extends itself", path)
+		}
+		loaded[path] = true
+
+		filename, ok := ResourceFilename(projectPath, path)
+		if !ok {
+			return nil, fmt.Errorf("%s isn't a res:// path", path)
+		}
+
+		data, err := read(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens, _ := lexer.NewScanner(string(data)).ScanTokens()
+		file, _ := parser.NewParser(tokens).Parse()
+
+		class.Properties = append(class.Properties, ScriptProperties(file, path)...)
+
+		extends := file.Extends
+		if extends == nil {
+			class.Base = "RefCounted"
+			return class, nil
+		}
+
+		// the inner classes after a path or class_name
+		var inner []*parser.Identifier
+
+		// a class_name is followed like a path, other names are built-in classes
+		if extends.Path != nil {
+			extended, _ := extends.Path.Constant.(string)
+			path = resolveScriptPath(path, extended)
+			inner = extends.Names
+		} else if script, ok := classScript(classes, extends.Names); ok {
+			path = script
+			inner = extends.Names[1:]
+		} else {
+			names := make([]string, 0, len(extends.Names))
+			for _, name := range extends.Names {
+				names = append(names, name.Name)
+			}
+			class.Base = strings.Join(names, ".")
+			return class, nil
+		}
+
+		// inner classes of other scripts aren't followed
+		if len(inner) > 0 {
+			class.Base = ""
+			return class, nil
+		}
+	}
+}
+
+// resolves a path in extends, which is relative to the directory of the script unless it's a res:// path
+func resolveScriptPath(script string, extended string) string {
+	if strings.HasPrefix(extended, "res://") {
+		return extended
+	}
+
+	dir := path.Dir(strings.TrimPrefix(script, "res://"))
+	return "res://" + path.Join(dir, extended)
+}
+
+// finds the script declaring the class_name an extends clause starts with
+func classScript(classes *ClassIndex, names []*parser.Identifier) (string, bool) {
+	if classes == nil || len(names) == 0 {
+		return "", false
+	}
+
+	return classes.Path(names[0].Name)
+}
+
+// lists the variables declared in the body of a script, leaving out static variables
+// since they belong to the script rather than to its instances
+func ScriptProperties(file *parser.File, path string) []ScriptProperty {
+	properties := make([]ScriptProperty, 0)
+
+	for _, member := range file.Members {
+		declaration, ok := member.(*parser.VarDecl)
+		if !ok || declaration.Static || declaration.Name == nil {
+			continue
+		}
+
+		exported := false
+		for _, annotation := range declaration.Annotations {
+			if annotation.Name != nil && strings.HasPrefix(annotation.Name.Name, "export") {
+				exported = true
+			}
+		}
+
+		properties = append(properties, ScriptProperty{
+			Name:     declaration.Name.Name,
+			Exported: exported,
+			Path:     path,
+			Range:    declaration.Name.Span(),
+		})
+	}
+
+	return properties
+}
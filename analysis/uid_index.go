@@ -0,0 +1,135 @@
+package analysis
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gdx/analysis/variant"
+)
+
+// maps the uid:// identifiers Godot gives files to their res:// paths and back
+type UIDIndex struct {
+	paths map[string]string
+	uids  map[string]string
+}
+
+func NewUIDIndex() *UIDIndex {
+	return &UIDIndex{
+		paths: make(map[string]string),
+		uids:  make(map[string]string),
+	}
+}
+
+func (i *UIDIndex) Add(uid string, path string) {
+	i.paths[uid] = path
+	i.uids[path] = uid
+}
+
+// finds the res:// path of the file with the UID
+func (i *UIDIndex) Path(uid string) (string, bool) {
+	path, ok := i.paths[uid]
+	return path, ok
+}
+
+// finds the UID of the file at a res:// path
+func (i *UIDIndex) UID(path string) (string, bool) {
+	uid, ok := i.uids[path]
+	return uid, ok
+}
+
+func (i *UIDIndex) Len() int {
+	return len(i.paths)
+}
+
+// checks if a file is one of the files UIDs are read from
+func IsUIDSource(filename string) bool {
+	switch filepath.Ext(filename) {
+	case ".uid", ".import", ".tscn", ".tres":
+		return true
+	}
+
+	return false
+}
+
+// finds the UIDs of the files in a project. Scripts and other files without a header of their
+// own have them in a .uid file next to them, imported files in their .import file, and scenes
+// and resources in their header. Files that can't be read are skipped
+func BuildUIDIndex(projectPath string) (*UIDIndex, error) {
+	index := NewUIDIndex()
+
+	err := walkProject(projectPath, func(filename string, path string) {
+		if !IsUIDSource(filename) {
+			return
+		}
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return
+		}
+
+		switch filepath.Ext(filename) {
+		case ".uid":
+			if uid := strings.TrimSpace(string(data)); strings.HasPrefix(uid, "uid://") {
+				index.Add(uid, strings.TrimSuffix(path, ".uid"))
+			}
+		case ".import":
+			config, _ := ParseConfigFile(data)
+			if uid, ok := ConfigValue[string](config, "remap", "uid"); ok {
+				index.Add(uid, strings.TrimSuffix(path, ".import"))
+			}
+		default:
+			if uid, ok := headerUID(data); ok {
+				index.Add(uid, path)
+			}
+		}
+	})
+
+	return index, err
+}
+
+// calls visit with the path on disk and the res:// path of every file in a project. Like the
+// Godot editor, directories with a .gdignore file and hidden directories such as .godot are skipped
+func walkProject(projectPath string, visit func(filename string, path string)) error {
+	return filepath.WalkDir(projectPath, func(filename string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if filename == projectPath {
+				return err
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			if filename != projectPath && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			if _, err := os.Stat(filepath.Join(filename, ".gdignore")); err == nil {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		relative, err := filepath.Rel(projectPath, filename)
+		if err != nil {
+			return nil
+		}
+
+		visit(filename, "res://"+filepath.ToSlash(relative))
+		return nil
+	})
+}
+
+// reads the uid field of the [gd_scene] or [gd_resource] tag on the first line of a file
+func headerUID(data []byte) (string, bool) {
+	line, _, _ := strings.Cut(string(data), "\n")
+
+	entries, _ := variant.ParseFile(line)
+	if len(entries) == 0 || entries[0].Tag == nil {
+		return "", false
+	}
+
+	return tagField[string](entries[0].Tag, "uid")
+}
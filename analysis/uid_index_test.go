@@ -0,0 +1,39 @@
+package analysis_test
+
+import (
+	"gdx/analysis"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildUIDIndex(t *testing.T) {
+	index, err := analysis.BuildUIDIndex(filepath.Join("..", "testdata", "project"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		uid  string
+		path string
+	}{
+		{uid: "uid://c1m5tvm3ejy7h", path: "res://player.gd"},
+		{uid: "uid://bq4lxhyd0xvk2", path: "res://player.tscn"},
+		{uid: "uid://b7r3nq2c5yk1m", path: "res://sword.tres"},
+		{uid: "uid://bx7k2mh3qt5ne", path: "res://icon.svg"},
+	}
+
+	for _, test := range tests {
+		if path, ok := index.Path(test.uid); !ok || path != test.path {
+			t.Errorf("expected %s to be %s, got %q", test.uid, test.path, path)
+		}
+
+		if uid, ok := index.UID(test.path); !ok || uid != test.uid {
+			t.Errorf("expected %s to have the UID %s, got %q", test.path, test.uid, uid)
+		}
+	}
+
+	// the .godot directory is only Godot's cache
+	if path, ok := index.Path("uid://stale00000000"); ok {
+		t.Errorf("expected files in .godot to be skipped, got %s", path)
+	}
+}
@@ -0,0 +1,1105 @@
+package variant
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gdx/analysis/lexer"
+)
+
+type Position = lexer.Position
+
+type Range struct {
+	Start Position
+	End   Position
+}
+
+type SyntaxError struct {
+	Range   Range
+	Message string
+}
+
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at line %d, char %d: %s", e.Range.Start.Line, e.Range.Start.Column, e.Message)
+}
+
+// a [tag] starting a section, such as [application] or [node name="Player" type="Node2D"]
+type Tag struct {
+	Name      string
+	Fields    []TagField
+	Range     Range
+	NameRange Range
+}
+
+type TagField struct {
+	Name       string
+	Value      any
+	NameRange  Range
+	ValueRange Range
+}
+
+// finds the value of one of the tag's fields
+func (t *Tag) Get(name string) (any, bool) {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// a key=value line
+type Assignment struct {
+	Key        string
+	Value      any
+	KeyRange   Range
+	ValueRange Range
+}
+
+// a tag or an assignment, only one of the two is set
+type Entry struct {
+	Tag        *Tag
+	Assignment *Assignment
+}
+
+// used to unwind the parser back to the enclosing entry after a syntax error
+type bailout struct{}
+
+type parser struct {
+	source    string
+	current   int
+	line      int
+	lineStart int
+	errors    []*SyntaxError
+}
+
+func newParser(source string) *parser {
+	return &parser{
+		source: source,
+		line:   1,
+		errors: make([]*SyntaxError, 0),
+	}
+}
+
+// parses a file in Godot's text format, such as project.godot or a scene,
+// into its tags and assignments in the order they're written. Syntax errors
+// don't stop parsing, the parser skips to the next line that starts an entry
+func ParseFile(source string) ([]Entry, []*SyntaxError) {
+	p := newParser(source)
+	entries := make([]Entry, 0)
+
+	for {
+		p.skipSpace()
+		if p.isAtEnd() {
+			break
+		}
+
+		p.recoverable(func() {
+			if p.peek() == '[' {
+				entries = append(entries, Entry{Tag: p.tag()})
+			} else {
+				entries = append(entries, Entry{Assignment: p.assignment()})
+			}
+		})
+	}
+
+	return entries, p.errors
+}
+
+// parses a single value such as one saved with var_to_str
+func Parse(text string) (any, error) {
+	p := newParser(text)
+
+	var value any
+	p.recoverable(func() {
+		value = p.value()
+
+		p.skipSpace()
+		if !p.isAtEnd() {
+			p.fail(p.pos(), "unexpected '%s' after value", p.rest())
+		}
+	})
+
+	if len(p.errors) > 0 {
+		return nil, p.errors[0]
+	}
+
+	return value, nil
+}
+
+// runs fn, catching the bailout from a syntax error and skipping to the next entry
+func (p *parser) recoverable(fn func()) {
+	start := p.current
+
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+
+			p.synchronize(start)
+		}
+	}()
+
+	fn()
+}
+
+// skips to the next line that looks like the start of a tag or an assignment.
+// A value missing its closing bracket is only found at a later entry, such
+// as the next key, so the parser goes back to the start of the line the error
+// was found on if that line starts an entry
+func (p *parser) synchronize(start int) {
+	if p.lineStart > start {
+		p.current = p.lineStart
+		if p.atEntryStart() {
+			return
+		}
+	}
+
+	for !p.isAtEnd() {
+		p.skipLine()
+
+		if p.atEntryStart() {
+			return
+		}
+	}
+}
+
+func (p *parser) atEntryStart() bool {
+	line := p.source[p.current:]
+	if end := strings.IndexByte(line, '\n'); end != -1 {
+		line = line[:end]
+	}
+
+	if strings.HasPrefix(line, "[") {
+		return true
+	}
+
+	key, _, found := strings.Cut(line, "=")
+	if !found || key == "" {
+		return false
+	}
+
+	if quoted, ok := strings.CutPrefix(strings.TrimSpace(key), "\""); ok {
+		return strings.IndexByte(quoted, '"') == len(quoted)-1
+	}
+
+	return isKeyStart(key[0]) && !strings.ContainsAny(key, "\"{}[](),:")
+}
+
+func (p *parser) fail(start Position, format string, args ...any) {
+	end := p.pos()
+	if end.Offset <= start.Offset {
+		end = start
+		end.Column++
+		end.Offset++
+	}
+
+	p.errors = append(p.errors, &SyntaxError{
+		Range:   Range{Start: start, End: end},
+		Message: fmt.Sprintf(format, args...),
+	})
+
+	panic(bailout{})
+}
+
+func (p *parser) isAtEnd() bool {
+	return p.current >= len(p.source)
+}
+
+func (p *parser) pos() Position {
+	return Position{Line: p.line, Column: p.current - p.lineStart, Offset: p.current}
+}
+
+func (p *parser) peek() byte {
+	if p.isAtEnd() {
+		return 0
+	}
+
+	return p.source[p.current]
+}
+
+func (p *parser) advance() byte {
+	c := p.source[p.current]
+	p.current++
+
+	if c == '\n' {
+		p.line++
+		p.lineStart = p.current
+	}
+
+	return c
+}
+
+func (p *parser) match(c byte) bool {
+	if p.peek() != c || p.isAtEnd() {
+		return false
+	}
+
+	p.advance()
+	return true
+}
+
+func (p *parser) expect(c byte, context string) {
+	p.skipSpace()
+	if !p.match(c) {
+		p.fail(p.pos(), "expected '%c' %s, found %s", c, context, p.found())
+	}
+}
+
+// describes the next character for error messages
+func (p *parser) found() string {
+	if p.isAtEnd() {
+		return "end of file"
+	}
+
+	r, _ := utf8.DecodeRuneInString(p.source[p.current:])
+	if r == '\n' {
+		return "end of line"
+	}
+
+	return fmt.Sprintf("'%c'", r)
+}
+
+// the rest of the current line, for error messages
+func (p *parser) rest() string {
+	line := p.source[p.current:]
+	if end := strings.IndexByte(line, '\n'); end != -1 {
+		line = line[:end]
+	}
+
+	return strings.TrimSpace(line)
+}
+
+// skips whitespace, new lines and comments
+func (p *parser) skipSpace() {
+	for !p.isAtEnd() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.advance()
+		case ';':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) skipComment() {
+	for !p.isAtEnd() && p.peek() != '\n' {
+		p.advance()
+	}
+}
+
+func (p *parser) skipLine() {
+	for !p.isAtEnd() {
+		if p.advance() == '\n' {
+			return
+		}
+	}
+}
+
+// checks nothing but a comment follows an entry on its line
+func (p *parser) endOfLine() {
+	for !p.isAtEnd() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\r') {
+		p.advance()
+	}
+
+	if p.isAtEnd() || p.peek() == '\n' || p.peek() == ';' {
+		return
+	}
+
+	p.fail(p.pos(), "unexpected '%s' after value", p.rest())
+}
+
+func (p *parser) tag() *Tag {
+	start := p.pos()
+	p.advance()
+
+	nameStart := p.pos()
+	for !p.isAtEnd() && !isSpace(p.peek()) && p.peek() != ']' {
+		p.advance()
+	}
+
+	tag := &Tag{
+		Name:      p.source[nameStart.Offset:p.current],
+		Fields:    make([]TagField, 0),
+		NameRange: Range{Start: nameStart, End: p.pos()},
+	}
+
+	if tag.Name == "" {
+		p.fail(start, "expected a name after '['")
+	}
+
+	for {
+		p.skipSpace()
+		if p.match(']') {
+			break
+		}
+
+		if p.isAtEnd() {
+			p.fail(start, "unterminated tag '%s'", tag.Name)
+		}
+
+		fieldStart := p.pos()
+		for !p.isAtEnd() && isIdentifierChar(p.peek()) {
+			p.advance()
+		}
+
+		field := TagField{
+			Name:      p.source[fieldStart.Offset:p.current],
+			NameRange: Range{Start: fieldStart, End: p.pos()},
+		}
+
+		if field.Name == "" {
+			p.fail(fieldStart, "expected a field name in tag '%s', found %s", tag.Name, p.found())
+		}
+
+		p.expect('=', "after field name")
+		field.Value, field.ValueRange = p.rangedValue()
+		tag.Fields = append(tag.Fields, field)
+	}
+
+	tag.Range = Range{Start: start, End: p.pos()}
+	p.endOfLine()
+
+	return tag
+}
+
+func (p *parser) assignment() *Assignment {
+	start := p.pos()
+
+	var key string
+	keyRange := Range{Start: start}
+	if p.peek() == '"' {
+		key = p.string()
+		keyRange.End = p.pos()
+	} else {
+		for !p.isAtEnd() && p.peek() != '=' && p.peek() != '\n' {
+			p.advance()
+		}
+		key = strings.TrimRight(p.source[start.Offset:p.current], " \t\r")
+
+		keyRange.End = start
+		keyRange.End.Column += len(key)
+		keyRange.End.Offset += len(key)
+	}
+
+	if key == "" {
+		p.fail(start, "expected a key")
+	}
+
+	p.skipInlineSpace()
+	if !p.match('=') {
+		p.fail(start, "expected '=' after '%s'", key)
+	}
+
+	assignment := &Assignment{Key: key, KeyRange: keyRange}
+	assignment.Value, assignment.ValueRange = p.rangedValue()
+	p.endOfLine()
+
+	return assignment
+}
+
+func (p *parser) skipInlineSpace() {
+	for !p.isAtEnd() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.advance()
+	}
+}
+
+func (p *parser) rangedValue() (any, Range) {
+	p.skipSpace()
+	start := p.pos()
+	value := p.value()
+
+	return value, Range{Start: start, End: p.pos()}
+}
+
+func (p *parser) value() any {
+	p.skipSpace()
+	start := p.pos()
+
+	c := p.peek()
+	switch {
+	case p.isAtEnd():
+		p.fail(start, "expected a value, found end of file")
+	case c == '{':
+		return p.dictionary()
+	case c == '[':
+		return p.array()
+	case c == '"':
+		return p.string()
+	case c == '&':
+		p.advance()
+		if p.peek() != '"' {
+			p.fail(start, "expected a string after '&'")
+		}
+		return StringName(p.string())
+	case c == '^':
+		p.advance()
+		if p.peek() != '"' {
+			p.fail(start, "expected a string after '^'")
+		}
+		return NodePath(p.string())
+	case c == '-' || c == '+' || c == '.' || isDigit(c):
+		return p.number()
+	case isIdentifierStart(c):
+		return p.identifierValue()
+	}
+
+	p.fail(start, "expected a value, found %s", p.found())
+	return nil
+}
+
+func (p *parser) dictionary() Dictionary {
+	p.advance()
+	dictionary := make(Dictionary, 0)
+
+	for {
+		p.skipSpace()
+		if p.match('}') {
+			return dictionary
+		}
+
+		key := p.value()
+		p.expect(':', "after dictionary key")
+		value := p.value()
+		dictionary = append(dictionary, DictionaryEntry{Key: key, Value: value})
+
+		p.skipSpace()
+		if p.match(',') {
+			continue
+		}
+
+		p.expect('}', "at the end of the dictionary")
+		return dictionary
+	}
+}
+
+func (p *parser) array() Array {
+	p.advance()
+	array := make(Array, 0)
+
+	for {
+		p.skipSpace()
+		if p.match(']') {
+			return array
+		}
+
+		array = append(array, p.value())
+
+		p.skipSpace()
+		if p.match(',') {
+			continue
+		}
+
+		p.expect(']', "at the end of the array")
+		return array
+	}
+}
+
+func (p *parser) string() string {
+	start := p.pos()
+	p.advance()
+
+	var builder strings.Builder
+	for {
+		if p.isAtEnd() {
+			p.fail(start, "unterminated string")
+		}
+
+		c := p.advance()
+		if c == '"' {
+			return builder.String()
+		}
+
+		if c != '\\' {
+			builder.WriteByte(c)
+			continue
+		}
+
+		if p.isAtEnd() {
+			p.fail(start, "unterminated string")
+		}
+
+		escapeStart := p.pos()
+		escape := p.advance()
+		switch escape {
+		case 'n':
+			builder.WriteByte('\n')
+		case 't':
+			builder.WriteByte('\t')
+		case 'r':
+			builder.WriteByte('\r')
+		case 'b':
+			builder.WriteByte('\b')
+		case 'f':
+			builder.WriteByte('\f')
+		case 'u', 'U':
+			digits := 4
+			if escape == 'U' {
+				digits = 6
+			}
+
+			if p.current+digits > len(p.source) {
+				p.fail(escapeStart, "incomplete unicode escape sequence")
+			}
+
+			code, err := strconv.ParseUint(p.source[p.current:p.current+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				p.fail(escapeStart, "invalid unicode escape sequence")
+			}
+
+			p.current += digits
+			builder.WriteRune(rune(code))
+		default:
+			// quotes, backslashes and any other escaped character stand for themselves
+			builder.WriteByte(escape)
+		}
+	}
+}
+
+func (p *parser) number() any {
+	start := p.pos()
+
+	if p.peek() == '-' || p.peek() == '+' {
+		sign := p.advance()
+
+		// -inf is written by older versions
+		if p.peek() == 'i' {
+			identifier := p.identifier()
+			if identifier != "inf" {
+				p.fail(start, "expected a number after '%c'", sign)
+			}
+
+			if sign == '-' {
+				return math.Inf(-1)
+			}
+			return math.Inf(1)
+		}
+	}
+
+	isFloat := false
+scan:
+	for !p.isAtEnd() {
+		c := p.peek()
+		switch {
+		case isDigit(c):
+		case c == '.':
+			isFloat = true
+		case c == 'e' || c == 'E':
+			isFloat = true
+			p.advance()
+			if p.peek() == '-' || p.peek() == '+' {
+				p.advance()
+			}
+			continue
+		default:
+			break scan
+		}
+		p.advance()
+	}
+
+	text := p.source[start.Offset:p.current]
+
+	if isFloat {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			p.fail(start, "invalid float '%s'", text)
+		}
+		return value
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		p.fail(start, "invalid integer '%s'", text)
+	}
+	return value
+}
+
+func (p *parser) identifier() string {
+	start := p.current
+	for !p.isAtEnd() && isIdentifierChar(p.peek()) {
+		p.advance()
+	}
+
+	return p.source[start:p.current]
+}
+
+func (p *parser) identifierValue() any {
+	start := p.pos()
+	name := p.identifier()
+
+	switch name {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null", "nil":
+		return nil
+	case "inf":
+		return math.Inf(1)
+	case "inf_neg":
+		return math.Inf(-1)
+	case "nan":
+		return math.NaN()
+	case "Object":
+		return p.object()
+	case "Array":
+		return p.typedArray(start)
+	case "Dictionary":
+		return p.typedDictionary(start)
+	}
+
+	p.expect('(', fmt.Sprintf("after '%s'", name))
+	args, ranges := p.arguments()
+
+	value, err := construct(name, args)
+	if err != nil {
+		if err.index >= 0 {
+			p.errorAt(ranges[err.index], err.message)
+		}
+		p.errorAt(Range{Start: start, End: p.pos()}, err.message)
+	}
+
+	return value
+}
+
+// records an error for part of a value that was parsed successfully
+func (p *parser) errorAt(at Range, message string) {
+	p.errors = append(p.errors, &SyntaxError{Range: at, Message: message})
+	panic(bailout{})
+}
+
+// parses the arguments of a constructor after its opening parenthesis
+func (p *parser) arguments() ([]any, []Range) {
+	args := make([]any, 0)
+	ranges := make([]Range, 0)
+
+	for {
+		p.skipSpace()
+		if p.match(')') {
+			return args, ranges
+		}
+
+		value, at := p.rangedValue()
+		args = append(args, value)
+		ranges = append(ranges, at)
+
+		p.skipSpace()
+		if p.match(',') {
+			continue
+		}
+
+		p.expect(')', "at the end of the arguments")
+		return args, ranges
+	}
+}
+
+func (p *parser) object() Object {
+	p.expect('(', "after 'Object'")
+	p.skipSpace()
+
+	object := Object{Class: p.identifier(), Properties: make([]Property, 0)}
+	if object.Class == "" {
+		p.fail(p.pos(), "expected a class name, found %s", p.found())
+	}
+
+	for {
+		p.skipSpace()
+		if p.match(')') {
+			return object
+		}
+
+		p.expect(',', "between object properties")
+		p.skipSpace()
+
+		// a trailing comma
+		if p.match(')') {
+			return object
+		}
+
+		if p.peek() != '"' {
+			p.fail(p.pos(), "expected a property name, found %s", p.found())
+		}
+
+		name := p.string()
+		p.expect(':', "after property name")
+		object.Properties = append(object.Properties, Property{Name: name, Value: p.value()})
+	}
+}
+
+// parses Array[Type]([...]), or Array([...]) without a type
+func (p *parser) typedArray(start Position) any {
+	p.skipSpace()
+	if !p.match('[') {
+		p.expect('(', "after 'Array'")
+		array := p.value()
+		p.expect(')', "after the array")
+
+		if _, ok := array.(Array); !ok {
+			p.fail(start, "expected an array inside 'Array()'")
+		}
+		return array
+	}
+
+	elementType := p.typeName()
+	p.expect(']', "after the array's type")
+	p.expect('(', "after 'Array'")
+
+	array, ok := p.value().(Array)
+	if !ok {
+		p.fail(start, "expected an array inside 'Array[%s]()'", elementType)
+	}
+	p.expect(')', "after the array")
+
+	return TypedArray{Type: elementType, Elements: array}
+}
+
+// parses Dictionary[Key, Value]({...}), or Dictionary({...}) without types
+func (p *parser) typedDictionary(start Position) any {
+	p.skipSpace()
+	if !p.match('[') {
+		p.expect('(', "after 'Dictionary'")
+		dictionary := p.value()
+		p.expect(')', "after the dictionary")
+
+		if _, ok := dictionary.(Dictionary); !ok {
+			p.fail(start, "expected a dictionary inside 'Dictionary()'")
+		}
+		return dictionary
+	}
+
+	keyType := p.typeName()
+	p.expect(',', "between the dictionary's key and value types")
+	valueType := p.typeName()
+	p.expect(']', "after the dictionary's types")
+	p.expect('(', "after 'Dictionary'")
+
+	dictionary, ok := p.value().(Dictionary)
+	if !ok {
+		p.fail(start, "expected a dictionary inside 'Dictionary[%s, %s]()'", keyType, valueType)
+	}
+	p.expect(')', "after the dictionary")
+
+	return TypedDictionary{KeyType: keyType, ValueType: valueType, Entries: dictionary}
+}
+
+// parses the type of a typed array or dictionary's elements. Script classes
+// are written as ExtResource("id") so the whole type is kept as written
+func (p *parser) typeName() string {
+	p.skipSpace()
+	start := p.current
+
+	depth := 0
+	for !p.isAtEnd() {
+		c := p.peek()
+		if depth == 0 && (c == ',' || c == ']') {
+			break
+		}
+
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		p.advance()
+	}
+
+	name := strings.TrimSpace(p.source[start:p.current])
+	if name == "" {
+		p.fail(p.pos(), "expected a type name, found %s", p.found())
+	}
+
+	return name
+}
+
+// an error building a value from a constructor's arguments. The index is the
+// argument the error is about, or -1 if it's about the whole constructor
+type constructError struct {
+	index   int
+	message string
+}
+
+// builds the value of a constructor such as Vector2(1, 2) from its arguments
+func construct(name string, args []any) (any, *constructError) {
+	switch name {
+	case "Vector2":
+		n, err := floats(name, args, 2)
+		return Vector2{n[0], n[1]}, err
+	case "Vector2i":
+		n, err := ints(name, args, 2)
+		return Vector2i{n[0], n[1]}, err
+	case "Vector3":
+		n, err := floats(name, args, 3)
+		return Vector3{n[0], n[1], n[2]}, err
+	case "Vector3i":
+		n, err := ints(name, args, 3)
+		return Vector3i{n[0], n[1], n[2]}, err
+	case "Vector4":
+		n, err := floats(name, args, 4)
+		return Vector4{n[0], n[1], n[2], n[3]}, err
+	case "Vector4i":
+		n, err := ints(name, args, 4)
+		return Vector4i{n[0], n[1], n[2], n[3]}, err
+	case "Rect2":
+		n, err := floats(name, args, 4)
+		return Rect2{Vector2{n[0], n[1]}, Vector2{n[2], n[3]}}, err
+	case "Rect2i":
+		n, err := ints(name, args, 4)
+		return Rect2i{Vector2i{n[0], n[1]}, Vector2i{n[2], n[3]}}, err
+	case "Color":
+		if len(args) == 3 {
+			n, err := floats(name, args, 3)
+			return Color{n[0], n[1], n[2], 1}, err
+		}
+		n, err := floats(name, args, 4)
+		return Color{n[0], n[1], n[2], n[3]}, err
+	case "Plane":
+		n, err := floats(name, args, 4)
+		return Plane{Vector3{n[0], n[1], n[2]}, n[3]}, err
+	case "Quaternion", "Quat":
+		n, err := floats(name, args, 4)
+		return Quaternion{n[0], n[1], n[2], n[3]}, err
+	case "AABB", "Rect3":
+		n, err := floats(name, args, 6)
+		return AABB{Vector3{n[0], n[1], n[2]}, Vector3{n[3], n[4], n[5]}}, err
+	case "Basis", "Matrix3":
+		n, err := floats(name, args, 9)
+		return basis(n), err
+	case "Transform2D", "Matrix32":
+		n, err := floats(name, args, 6)
+		return Transform2D{Vector2{n[0], n[1]}, Vector2{n[2], n[3]}, Vector2{n[4], n[5]}}, err
+	case "Transform3D", "Transform":
+		n, err := floats(name, args, 12)
+		return Transform3D{basis(n[:9]), Vector3{n[9], n[10], n[11]}}, err
+	case "Projection":
+		n, err := floats(name, args, 16)
+		return Projection{
+			Vector4{n[0], n[1], n[2], n[3]},
+			Vector4{n[4], n[5], n[6], n[7]},
+			Vector4{n[8], n[9], n[10], n[11]},
+			Vector4{n[12], n[13], n[14], n[15]},
+		}, err
+	case "NodePath":
+		s, err := singleString(name, args)
+		return NodePath(s), err
+	case "StringName":
+		s, err := singleString(name, args)
+		return StringName(s), err
+	case "RID":
+		return RID{}, nil
+	case "Callable":
+		return Callable{}, nil
+	case "Signal":
+		return Signal{}, nil
+	case "Resource":
+		s, err := singleString(name, args)
+		return Resource{Path: s}, err
+	case "ExtResource":
+		id, err := resourceID(name, args)
+		return ExtResource{ID: id}, err
+	case "SubResource":
+		id, err := resourceID(name, args)
+		return SubResource{ID: id}, err
+	case "PackedByteArray", "PoolByteArray":
+		return packedBytes(name, args)
+	case "PackedInt32Array", "PoolIntArray":
+		n, err := ints(name, args, len(args))
+		packed := make(PackedInt32Array, len(n))
+		for i, value := range n {
+			if value < math.MinInt32 || value > math.MaxInt32 {
+				return nil, &constructError{i, fmt.Sprintf("%d doesn't fit in a 32-bit integer", value)}
+			}
+			packed[i] = int32(value)
+		}
+		return packed, err
+	case "PackedInt64Array":
+		n, err := ints(name, args, len(args))
+		return PackedInt64Array(n), err
+	case "PackedFloat32Array", "PoolRealArray":
+		n, err := floats(name, args, len(args))
+		packed := make(PackedFloat32Array, len(n))
+		for i, value := range n {
+			packed[i] = float32(value)
+		}
+		return packed, err
+	case "PackedFloat64Array":
+		n, err := floats(name, args, len(args))
+		return PackedFloat64Array(n), err
+	case "PackedStringArray", "PoolStringArray":
+		packed := make(PackedStringArray, len(args))
+		for i, arg := range args {
+			s, ok := arg.(string)
+			if !ok {
+				return nil, &constructError{i, fmt.Sprintf("expected a string in %s", name)}
+			}
+			packed[i] = s
+		}
+		return packed, nil
+	case "PackedVector2Array", "PoolVector2Array":
+		n, err := groups(name, args, 2)
+		packed := make(PackedVector2Array, len(n)/2)
+		for i := range packed {
+			packed[i] = Vector2{n[i*2], n[i*2+1]}
+		}
+		return packed, err
+	case "PackedVector3Array", "PoolVector3Array":
+		n, err := groups(name, args, 3)
+		packed := make(PackedVector3Array, len(n)/3)
+		for i := range packed {
+			packed[i] = Vector3{n[i*3], n[i*3+1], n[i*3+2]}
+		}
+		return packed, err
+	case "PackedVector4Array":
+		n, err := groups(name, args, 4)
+		packed := make(PackedVector4Array, len(n)/4)
+		for i := range packed {
+			packed[i] = Vector4{n[i*4], n[i*4+1], n[i*4+2], n[i*4+3]}
+		}
+		return packed, err
+	case "PackedColorArray", "PoolColorArray":
+		n, err := groups(name, args, 4)
+		packed := make(PackedColorArray, len(n)/4)
+		for i := range packed {
+			packed[i] = Color{n[i*4], n[i*4+1], n[i*4+2], n[i*4+3]}
+		}
+		return packed, err
+	}
+
+	return nil, &constructError{-1, fmt.Sprintf("unknown type '%s'", name)}
+}
+
+// a basis from its 9 values, which are written row by row
+func basis(n []float64) Basis {
+	return Basis{
+		X: Vector3{n[0], n[3], n[6]},
+		Y: Vector3{n[1], n[4], n[7]},
+		Z: Vector3{n[2], n[5], n[8]},
+	}
+}
+
+// reads count numeric arguments as floats. The slice returned always has
+// count values so the caller can build its value before checking the error
+func floats(name string, args []any, count int) ([]float64, *constructError) {
+	n := make([]float64, count)
+
+	if len(args) != count {
+		return n, &constructError{-1, fmt.Sprintf("%s expects %d arguments, found %d", name, count, len(args))}
+	}
+
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case int64:
+			n[i] = float64(arg)
+		case float64:
+			n[i] = arg
+		default:
+			return n, &constructError{i, fmt.Sprintf("expected a number in %s", name)}
+		}
+	}
+
+	return n, nil
+}
+
+func ints(name string, args []any, count int) ([]int64, *constructError) {
+	n := make([]int64, count)
+
+	if len(args) != count {
+		return n, &constructError{-1, fmt.Sprintf("%s expects %d arguments, found %d", name, count, len(args))}
+	}
+
+	for i, arg := range args {
+		value, ok := arg.(int64)
+		if !ok {
+			return n, &constructError{i, fmt.Sprintf("expected an integer in %s", name)}
+		}
+		n[i] = value
+	}
+
+	return n, nil
+}
+
+// reads the numbers of a packed array of vectors or colors, which must come in groups of size
+func groups(name string, args []any, size int) ([]float64, *constructError) {
+	if len(args)%size != 0 {
+		return nil, &constructError{-1, fmt.Sprintf("%s expects a multiple of %d numbers, found %d", name, size, len(args))}
+	}
+
+	return floats(name, args, len(args))
+}
+
+func singleString(name string, args []any) (string, *constructError) {
+	if len(args) != 1 {
+		return "", &constructError{-1, fmt.Sprintf("%s expects 1 argument, found %d", name, len(args))}
+	}
+
+	s, ok := args[0].(string)
+	if !ok {
+		return "", &constructError{0, fmt.Sprintf("expected a string in %s", name)}
+	}
+
+	return s, nil
+}
+
+// reads the ID of an ExtResource or SubResource, older versions use integers
+func resourceID(name string, args []any) (string, *constructError) {
+	if len(args) == 1 {
+		if id, ok := args[0].(int64); ok {
+			return strconv.FormatInt(id, 10), nil
+		}
+	}
+
+	return singleString(name, args)
+}
+
+// reads a PackedByteArray written as its bytes or as a base64 string
+func packedBytes(name string, args []any) (any, *constructError) {
+	if len(args) == 1 {
+		if encoded, ok := args[0].(string); ok {
+			decoded, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, &constructError{0, "invalid base64 in PackedByteArray"}
+			}
+			return PackedByteArray(decoded), nil
+		}
+	}
+
+	n, err := ints(name, args, len(args))
+	packed := make(PackedByteArray, len(n))
+	for i, value := range n {
+		if value < 0 || value > 255 {
+			return nil, &constructError{i, fmt.Sprintf("%d isn't a byte", value)}
+		}
+		packed[i] = byte(value)
+	}
+
+	return packed, err
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentifierStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isIdentifierChar(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c)
+}
+
+// keys are usually paths such as config/name
+func isKeyStart(c byte) bool {
+	return isIdentifierStart(c) || isDigit(c)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
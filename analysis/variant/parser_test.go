@@ -0,0 +1,209 @@
+package variant_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gdx/analysis/variant"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{name: "Null", input: "null", expected: nil},
+		{name: "Bool", input: "true", expected: true},
+		{name: "Int", input: "-12", expected: int64(-12)},
+		{name: "Float", input: "0.5", expected: 0.5},
+		{name: "Exponent", input: "1e+06", expected: 1e6},
+		{name: "Infinity", input: "inf_neg", expected: math.Inf(-1)},
+		{name: "String", input: `"say \"hi\"\né"`, expected: "say \"hi\"\né"},
+		{name: "StringName", input: `&"jump"`, expected: variant.StringName("jump")},
+		{name: "NodePath", input: `^"Player/Sprite2D"`, expected: variant.NodePath("Player/Sprite2D")},
+		{name: "NodePath constructor", input: `NodePath("Player:position")`, expected: variant.NodePath("Player:position")},
+		{name: "Vector2", input: "Vector2(1, 2.5)", expected: variant.Vector2{X: 1, Y: 2.5}},
+		{name: "Vector2i", input: "Vector2i(1152, 648)", expected: variant.Vector2i{X: 1152, Y: 648}},
+		{name: "Vector3", input: "Vector3(0, -1, 0)", expected: variant.Vector3{X: 0, Y: -1, Z: 0}},
+		{name: "Vector4i", input: "Vector4i(1, 2, 3, 4)", expected: variant.Vector4i{X: 1, Y: 2, Z: 3, W: 4}},
+		{name: "Color", input: "Color(1, 0.5, 0, 1)", expected: variant.Color{R: 1, G: 0.5, B: 0, A: 1}},
+		{name: "Color without alpha", input: "Color(0, 0, 0)", expected: variant.Color{A: 1}},
+		{name: "Rect2", input: "Rect2(0, 0, 64, 32)", expected: variant.Rect2{Size: variant.Vector2{X: 64, Y: 32}}},
+		{
+			name:     "Transform2D",
+			input:    "Transform2D(1, 0, 0, 1, 10, 20)",
+			expected: variant.Transform2D{X: variant.Vector2{X: 1}, Y: variant.Vector2{Y: 1}, Origin: variant.Vector2{X: 10, Y: 20}},
+		},
+		{
+			name:  "Transform3D",
+			input: "Transform3D(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12)",
+			expected: variant.Transform3D{
+				Basis:  variant.Basis{X: variant.Vector3{X: 1, Y: 4, Z: 7}, Y: variant.Vector3{X: 2, Y: 5, Z: 8}, Z: variant.Vector3{X: 3, Y: 6, Z: 9}},
+				Origin: variant.Vector3{X: 10, Y: 11, Z: 12},
+			},
+		},
+		{
+			name:     "PackedStringArray",
+			input:    `PackedStringArray("4.4", "GL Compatibility")`,
+			expected: variant.PackedStringArray{"4.4", "GL Compatibility"},
+		},
+		{name: "Empty PackedInt32Array", input: "PackedInt32Array()", expected: variant.PackedInt32Array{}},
+		{name: "PackedByteArray", input: "PackedByteArray(0, 255)", expected: variant.PackedByteArray{0, 255}},
+		{name: "Base64 PackedByteArray", input: `PackedByteArray("AP8=")`, expected: variant.PackedByteArray{0, 255}},
+		{
+			name:     "PackedVector2Array",
+			input:    "PackedVector2Array(0, 1, 2, 3)",
+			expected: variant.PackedVector2Array{{X: 0, Y: 1}, {X: 2, Y: 3}},
+		},
+		{
+			name:     "Array",
+			input:    `[1, "two", [3], ]`,
+			expected: variant.Array{int64(1), "two", variant.Array{int64(3)}},
+		},
+		{
+			name:     "Typed array",
+			input:    `Array[StringName]([&"a"])`,
+			expected: variant.TypedArray{Type: "StringName", Elements: variant.Array{variant.StringName("a")}},
+		},
+		{
+			name:  "Dictionary keeps order",
+			input: "{\n\"b\": 1,\n\"a\": {\n\"nested\": true\n}\n}",
+			expected: variant.Dictionary{
+				{Key: "b", Value: int64(1)},
+				{Key: "a", Value: variant.Dictionary{{Key: "nested", Value: true}}},
+			},
+		},
+		{
+			name:     "Typed dictionary",
+			input:    `Dictionary[String, int]({"a": 1})`,
+			expected: variant.TypedDictionary{KeyType: "String", ValueType: "int", Entries: variant.Dictionary{{Key: "a", Value: int64(1)}}},
+		},
+		{
+			name:  "Object",
+			input: `Object(InputEventKey,"resource_local_to_scene":false,"device":-1,"physical_keycode":87,"script":null)`,
+			expected: variant.Object{
+				Class: "InputEventKey",
+				Properties: []variant.Property{
+					{Name: "resource_local_to_scene", Value: false},
+					{Name: "device", Value: int64(-1)},
+					{Name: "physical_keycode", Value: int64(87)},
+					{Name: "script", Value: nil},
+				},
+			},
+		},
+		{name: "ExtResource", input: `ExtResource("1_abcde")`, expected: variant.ExtResource{ID: "1_abcde"}},
+		{name: "Old ExtResource", input: "ExtResource(3)", expected: variant.ExtResource{ID: "3"}},
+		{name: "SubResource", input: `SubResource("RectangleShape2D_x1")`, expected: variant.SubResource{ID: "RectangleShape2D_x1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := variant.Parse(test.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %#v, got %#v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `"unterminated`, expected: "syntax error at line 1, char 0: unterminated string"},
+		{input: "Vector2(1)", expected: "syntax error at line 1, char 0: Vector2 expects 2 arguments, found 1"},
+		{input: `Vector2i(1, "2")`, expected: "syntax error at line 1, char 12: expected an integer in Vector2i"},
+		{input: "Potato(1)", expected: "syntax error at line 1, char 0: unknown type 'Potato'"},
+		{input: "[1, 2", expected: "syntax error at line 1, char 5: expected ']' at the end of the array, found end of file"},
+		{input: "{\"a\" 1}", expected: "syntax error at line 1, char 5: expected ':' after dictionary key, found '1'"},
+		{input: "1 2", expected: "syntax error at line 1, char 2: unexpected '2' after value"},
+		{input: "PackedByteArray(256)", expected: "syntax error at line 1, char 16: 256 isn't a byte"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := variant.Parse(test.input)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if err.Error() != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, err.Error())
+			}
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	source := `; comment
+config_version=5
+
+[gd_scene load_steps=2 format=3 uid="uid://b1"]
+
+[application]
+config/name="Game" ; trailing comment
+broken={
+"a": 1,
+run/main_scene="res://main.tscn"
+"quoted key"=Vector2(1, 2)
+`
+
+	entries, errors := variant.ParseFile(source)
+
+	type entry struct {
+		tag    string
+		fields []string
+		key    string
+		value  any
+	}
+
+	actual := make([]entry, 0)
+	for _, e := range entries {
+		if e.Tag != nil {
+			fields := make([]string, 0)
+			for _, field := range e.Tag.Fields {
+				fields = append(fields, field.Name)
+			}
+			actual = append(actual, entry{tag: e.Tag.Name, fields: fields})
+		} else {
+			actual = append(actual, entry{key: e.Assignment.Key, value: e.Assignment.Value})
+		}
+	}
+
+	expected := []entry{
+		{key: "config_version", value: int64(5)},
+		{tag: "gd_scene", fields: []string{"load_steps", "format", "uid"}},
+		{tag: "application", fields: []string{}},
+		{key: "config/name", value: "Game"},
+		{key: "run/main_scene", value: "res://main.tscn"},
+		{key: "quoted key", value: variant.Vector2{X: 1, Y: 2}},
+	}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+
+	expectedErrors := []string{"syntax error at line 10, char 3: expected '(' after 'run', found '/'"}
+	actualErrors := make([]string, 0)
+	for _, err := range errors {
+		actualErrors = append(actualErrors, err.Error())
+	}
+
+	if !reflect.DeepEqual(actualErrors, expectedErrors) {
+		t.Errorf("expected errors %q, got %q", expectedErrors, actualErrors)
+	}
+
+	name := entries[3].Assignment
+	expectedKey := variant.Range{Start: variant.Position{Line: 7, Column: 0, Offset: 91}, End: variant.Position{Line: 7, Column: 11, Offset: 102}}
+	expectedValue := variant.Range{Start: variant.Position{Line: 7, Column: 12, Offset: 103}, End: variant.Position{Line: 7, Column: 18, Offset: 109}}
+	if name.KeyRange != expectedKey || name.ValueRange != expectedValue {
+		t.Errorf("expected ranges %+v and %+v, got %+v and %+v", expectedKey, expectedValue, name.KeyRange, name.ValueRange)
+	}
+}
@@ -0,0 +1,193 @@
+package variant
+
+import "reflect"
+
+// Values parsed from Godot's text format use these Go types:
+//
+//	null                      nil
+//	bool                      bool
+//	int                       int64
+//	float                     float64
+//	String                    string
+//	StringName, NodePath      StringName, NodePath
+//	Array, Dictionary         Array or TypedArray, Dictionary or TypedDictionary
+//	Packed*Array              the Packed*Array types
+//	Object(...)               Object
+//	ExtResource, SubResource  ExtResource, SubResource
+//
+// and the remaining built-in types have a struct of the same name.
+
+type StringName string
+
+type NodePath string
+
+type Vector2 struct {
+	X, Y float64
+}
+
+type Vector2i struct {
+	X, Y int64
+}
+
+type Vector3 struct {
+	X, Y, Z float64
+}
+
+type Vector3i struct {
+	X, Y, Z int64
+}
+
+type Vector4 struct {
+	X, Y, Z, W float64
+}
+
+type Vector4i struct {
+	X, Y, Z, W int64
+}
+
+type Rect2 struct {
+	Position, Size Vector2
+}
+
+type Rect2i struct {
+	Position, Size Vector2i
+}
+
+type Color struct {
+	R, G, B, A float64
+}
+
+type Plane struct {
+	Normal Vector3
+	D      float64
+}
+
+type Quaternion struct {
+	X, Y, Z, W float64
+}
+
+type AABB struct {
+	Position, Size Vector3
+}
+
+// a 3x3 matrix stored as its columns
+type Basis struct {
+	X, Y, Z Vector3
+}
+
+type Transform2D struct {
+	X, Y, Origin Vector2
+}
+
+type Transform3D struct {
+	Basis  Basis
+	Origin Vector3
+}
+
+// a 4x4 matrix stored as its columns
+type Projection struct {
+	X, Y, Z, W Vector4
+}
+
+type RID struct{}
+
+type Callable struct{}
+
+type Signal struct{}
+
+type Array []any
+
+// an array whose elements all have the same type, written as Array[int]([1, 2])
+type TypedArray struct {
+	Type     string
+	Elements Array
+}
+
+type DictionaryEntry struct {
+	Key   any
+	Value any
+}
+
+// a dictionary's entries in the order they were written
+type Dictionary []DictionaryEntry
+
+// finds the value for a key
+func (d Dictionary) Get(key any) (any, bool) {
+	for _, entry := range d {
+		if Equal(entry.Key, key) {
+			return entry.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// a dictionary with typed keys and values, written as Dictionary[String, int]({...})
+type TypedDictionary struct {
+	KeyType   string
+	ValueType string
+	Entries   Dictionary
+}
+
+type PackedByteArray []byte
+
+type PackedInt32Array []int32
+
+type PackedInt64Array []int64
+
+type PackedFloat32Array []float32
+
+type PackedFloat64Array []float64
+
+type PackedStringArray []string
+
+type PackedVector2Array []Vector2
+
+type PackedVector3Array []Vector3
+
+type PackedVector4Array []Vector4
+
+type PackedColorArray []Color
+
+type Property struct {
+	Name  string
+	Value any
+}
+
+// an object saved inline with its class and the properties that were set,
+// such as the input events in project.godot
+type Object struct {
+	Class      string
+	Properties []Property
+}
+
+// finds the value of a property
+func (o Object) Get(name string) (any, bool) {
+	for _, property := range o.Properties {
+		if property.Name == name {
+			return property.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// a resource declared with an [ext_resource] tag in the same file
+type ExtResource struct {
+	ID string
+}
+
+// a resource declared with a [sub_resource] tag in the same file
+type SubResource struct {
+	ID string
+}
+
+// a resource referenced by its path, used by older files
+type Resource struct {
+	Path string
+}
+
+// compares two values, used to look up dictionary keys
+func Equal(a any, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
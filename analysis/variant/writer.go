@@ -0,0 +1,273 @@
+package variant
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// formats a value the way Godot writes it to text files, so that Parse(Format(value))
+// gives back the same value. Go ints and float32s are accepted as well as the types
+// the parser returns
+func Format(value any) (string, error) {
+	var result strings.Builder
+	if err := write(&result, value); err != nil {
+		return "", err
+	}
+
+	return result.String(), nil
+}
+
+func write(w *strings.Builder, value any) error {
+	switch value := value.(type) {
+	case nil:
+		w.WriteString("null")
+	case bool:
+		w.WriteString(strconv.FormatBool(value))
+	case int:
+		w.WriteString(strconv.Itoa(value))
+	case int64:
+		w.WriteString(strconv.FormatInt(value, 10))
+	case float32:
+		w.WriteString(withDecimalPoint(formatNumber(value)))
+	case float64:
+		w.WriteString(withDecimalPoint(formatNumber(value)))
+	case string:
+		writeString(w, value)
+	case StringName:
+		w.WriteString("&")
+		writeString(w, string(value))
+	case NodePath:
+		w.WriteString("NodePath(")
+		writeString(w, string(value))
+		w.WriteString(")")
+	case Vector2:
+		writeConstructor(w, "Vector2", value.X, value.Y)
+	case Vector2i:
+		writeConstructor(w, "Vector2i", value.X, value.Y)
+	case Vector3:
+		writeConstructor(w, "Vector3", value.X, value.Y, value.Z)
+	case Vector3i:
+		writeConstructor(w, "Vector3i", value.X, value.Y, value.Z)
+	case Vector4:
+		writeConstructor(w, "Vector4", value.X, value.Y, value.Z, value.W)
+	case Vector4i:
+		writeConstructor(w, "Vector4i", value.X, value.Y, value.Z, value.W)
+	case Rect2:
+		writeConstructor(w, "Rect2", value.Position.X, value.Position.Y, value.Size.X, value.Size.Y)
+	case Rect2i:
+		writeConstructor(w, "Rect2i", value.Position.X, value.Position.Y, value.Size.X, value.Size.Y)
+	case Color:
+		writeConstructor(w, "Color", value.R, value.G, value.B, value.A)
+	case Plane:
+		writeConstructor(w, "Plane", value.Normal.X, value.Normal.Y, value.Normal.Z, value.D)
+	case Quaternion:
+		writeConstructor(w, "Quaternion", value.X, value.Y, value.Z, value.W)
+	case AABB:
+		writeConstructor(w, "AABB", value.Position.X, value.Position.Y, value.Position.Z, value.Size.X, value.Size.Y, value.Size.Z)
+	case Basis:
+		writeConstructor(w, "Basis", basisRows(value)...)
+	case Transform2D:
+		writeConstructor(w, "Transform2D", value.X.X, value.X.Y, value.Y.X, value.Y.Y, value.Origin.X, value.Origin.Y)
+	case Transform3D:
+		writeConstructor(w, "Transform3D", append(basisRows(value.Basis), value.Origin.X, value.Origin.Y, value.Origin.Z)...)
+	case Projection:
+		n := make([]float64, 0, 16)
+		for _, column := range []Vector4{value.X, value.Y, value.Z, value.W} {
+			n = append(n, column.X, column.Y, column.Z, column.W)
+		}
+		writeConstructor(w, "Projection", n...)
+	case RID:
+		w.WriteString("RID()")
+	case Callable:
+		w.WriteString("Callable()")
+	case Signal:
+		w.WriteString("Signal()")
+	case Array:
+		return writeArray(w, value)
+	case TypedArray:
+		fmt.Fprintf(w, "Array[%s](", value.Type)
+		if err := writeArray(w, value.Elements); err != nil {
+			return err
+		}
+		w.WriteString(")")
+	case Dictionary:
+		return writeDictionary(w, value)
+	case TypedDictionary:
+		fmt.Fprintf(w, "Dictionary[%s, %s](", value.KeyType, value.ValueType)
+		if err := writeDictionary(w, value.Entries); err != nil {
+			return err
+		}
+		w.WriteString(")")
+	case PackedByteArray:
+		writeConstructor(w, "PackedByteArray", value...)
+	case PackedInt32Array:
+		writeConstructor(w, "PackedInt32Array", value...)
+	case PackedInt64Array:
+		writeConstructor(w, "PackedInt64Array", value...)
+	case PackedFloat32Array:
+		writeConstructor(w, "PackedFloat32Array", value...)
+	case PackedFloat64Array:
+		writeConstructor(w, "PackedFloat64Array", value...)
+	case PackedStringArray:
+		w.WriteString("PackedStringArray(")
+		for i, s := range value {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			writeString(w, s)
+		}
+		w.WriteString(")")
+	case PackedVector2Array:
+		n := make([]float64, 0, len(value)*2)
+		for _, v := range value {
+			n = append(n, v.X, v.Y)
+		}
+		writeConstructor(w, "PackedVector2Array", n...)
+	case PackedVector3Array:
+		n := make([]float64, 0, len(value)*3)
+		for _, v := range value {
+			n = append(n, v.X, v.Y, v.Z)
+		}
+		writeConstructor(w, "PackedVector3Array", n...)
+	case PackedVector4Array:
+		n := make([]float64, 0, len(value)*4)
+		for _, v := range value {
+			n = append(n, v.X, v.Y, v.Z, v.W)
+		}
+		writeConstructor(w, "PackedVector4Array", n...)
+	case PackedColorArray:
+		n := make([]float64, 0, len(value)*4)
+		for _, c := range value {
+			n = append(n, c.R, c.G, c.B, c.A)
+		}
+		writeConstructor(w, "PackedColorArray", n...)
+	case Object:
+		// Godot ends objects with a newline, which is why arrays of input events span several lines
+		fmt.Fprintf(w, "Object(%s", value.Class)
+		for _, property := range value.Properties {
+			w.WriteString(",")
+			writeString(w, property.Name)
+			w.WriteString(":")
+			if err := write(w, property.Value); err != nil {
+				return err
+			}
+		}
+		w.WriteString(")\n")
+	case ExtResource:
+		w.WriteString("ExtResource(")
+		writeString(w, value.ID)
+		w.WriteString(")")
+	case SubResource:
+		w.WriteString("SubResource(")
+		writeString(w, value.ID)
+		w.WriteString(")")
+	case Resource:
+		w.WriteString("Resource(")
+		writeString(w, value.Path)
+		w.WriteString(")")
+	default:
+		return fmt.Errorf("unable to format a value of type %T", value)
+	}
+
+	return nil
+}
+
+// strings only escape quotes and backslashes, newlines are written as they are
+func writeString(w *strings.Builder, s string) {
+	w.WriteString(`"`)
+	w.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s))
+	w.WriteString(`"`)
+}
+
+// a float on its own always has a decimal point so it is read back as a float
+func withDecimalPoint(text string) string {
+	if strings.ContainsAny(text, ".en") {
+		return text
+	}
+
+	return text + ".0"
+}
+
+// formats the arguments of constructors, which are written without a decimal point when they're whole
+func formatNumber[T int64 | int32 | byte | float64 | float32](n T) string {
+	switch n := any(n).(type) {
+	case float64:
+		return formatFloat64(n, 64)
+	case float32:
+		return formatFloat64(float64(n), 32)
+	}
+
+	return fmt.Sprint(n)
+}
+
+func formatFloat64(f float64, bits int) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "inf_neg"
+	case math.IsNaN(f):
+		return "nan"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+func writeConstructor[T int64 | int32 | byte | float64 | float32](w *strings.Builder, name string, args ...T) {
+	w.WriteString(name)
+	w.WriteString("(")
+	for i, arg := range args {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString(formatNumber(arg))
+	}
+	w.WriteString(")")
+}
+
+func writeArray(w *strings.Builder, array Array) error {
+	w.WriteString("[")
+	for i, element := range array {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		if err := write(w, element); err != nil {
+			return err
+		}
+	}
+	w.WriteString("]")
+
+	return nil
+}
+
+// dictionaries that aren't empty are written with one entry per line
+func writeDictionary(w *strings.Builder, dictionary Dictionary) error {
+	if len(dictionary) == 0 {
+		w.WriteString("{}")
+		return nil
+	}
+
+	w.WriteString("{\n")
+	for i, entry := range dictionary {
+		if i > 0 {
+			w.WriteString(",\n")
+		}
+		if err := write(w, entry.Key); err != nil {
+			return err
+		}
+		w.WriteString(": ")
+		if err := write(w, entry.Value); err != nil {
+			return err
+		}
+	}
+	w.WriteString("\n}")
+
+	return nil
+}
+
+// the values of a basis row by row, the order they're written in
+func basisRows(b Basis) []float64 {
+	return []float64{b.X.X, b.Y.X, b.Z.X, b.X.Y, b.Y.Y, b.Z.Y, b.X.Z, b.Y.Z, b.Z.Z}
+}
@@ -0,0 +1,91 @@
+package variant_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gdx/analysis/variant"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{name: "Null", value: nil, expected: "null"},
+		{name: "Int", value: int64(-12), expected: "-12"},
+		{name: "Whole float", value: 2.0, expected: "2.0"},
+		{name: "Float", value: 0.2, expected: "0.2"},
+		{name: "Exponent", value: 1e6, expected: "1e+06"},
+		{name: "Infinity", value: math.Inf(-1), expected: "inf_neg"},
+		{name: "String", value: "say \"hi\"\n\\", expected: "\"say \\\"hi\\\"\n\\\\\""},
+		{name: "StringName", value: variant.StringName("jump"), expected: `&"jump"`},
+		{name: "NodePath", value: variant.NodePath("Player"), expected: `NodePath("Player")`},
+		{name: "Vector2", value: variant.Vector2{X: 1, Y: 2.5}, expected: "Vector2(1, 2.5)"},
+		{name: "Color", value: variant.Color{R: 1, G: 0.5, A: 1}, expected: "Color(1, 0.5, 0, 1)"},
+		{name: "PackedStringArray", value: variant.PackedStringArray{"4.4", "Forward Plus"}, expected: `PackedStringArray("4.4", "Forward Plus")`},
+		{name: "Empty dictionary", value: variant.Dictionary{}, expected: "{}"},
+		{
+			name: "Input action",
+			value: variant.Dictionary{
+				{Key: "deadzone", Value: 0.5},
+				{Key: "events", Value: variant.Array{
+					variant.Object{Class: "InputEventKey", Properties: []variant.Property{{Name: "keycode", Value: int64(87)}, {Name: "script", Value: nil}}},
+				}},
+			},
+			expected: "{\n\"deadzone\": 0.5,\n\"events\": [Object(InputEventKey,\"keycode\":87,\"script\":null)\n]\n}",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := variant.Format(test.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if actual != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	values := []any{
+		true,
+		0.1,
+		variant.Vector3i{X: 1, Y: -2, Z: 3},
+		variant.Rect2{Position: variant.Vector2{X: 0.5}, Size: variant.Vector2{X: 64, Y: 32}},
+		variant.Transform3D{
+			Basis:  variant.Basis{X: variant.Vector3{X: 1, Y: 4, Z: 7}, Y: variant.Vector3{X: 2, Y: 5, Z: 8}, Z: variant.Vector3{X: 3, Y: 6, Z: 9}},
+			Origin: variant.Vector3{X: 10, Y: 11, Z: 12},
+		},
+		variant.Transform2D{X: variant.Vector2{X: 1, Y: 2}, Y: variant.Vector2{X: 3, Y: 4}, Origin: variant.Vector2{X: 5, Y: 6}},
+		variant.TypedArray{Type: "StringName", Elements: variant.Array{variant.StringName("a")}},
+		variant.TypedDictionary{KeyType: "String", ValueType: "int", Entries: variant.Dictionary{{Key: "a", Value: int64(1)}}},
+		variant.PackedByteArray{0, 255},
+		variant.PackedFloat32Array{0.1, 2},
+		variant.PackedVector2Array{{X: 0, Y: 1}, {X: 2, Y: 3}},
+		variant.ExtResource{ID: "1_abcde"},
+		variant.SubResource{ID: "RectangleShape2D_x1"},
+	}
+
+	for _, value := range values {
+		text, err := variant.Format(value)
+		if err != nil {
+			t.Fatalf("unable to format %#v: %v", value, err)
+		}
+
+		actual, err := variant.Parse(text)
+		if err != nil {
+			t.Fatalf("unable to parse %q: %v", text, err)
+		}
+
+		if !reflect.DeepEqual(actual, value) {
+			t.Errorf("expected %#v, got %#v from %q", value, actual, text)
+		}
+	}
+}
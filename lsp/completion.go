@@ -1,10 +1,10 @@
 package lsp
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"gdx/rpc"
-	"log"
+	"log/slog"
 )
 
 type CompletionItemKind int
@@ -54,6 +54,7 @@ type CompletionRequest struct {
 }
 
 type CompletionRequestParams struct {
+	TextDocumentPositionParams
 	Context CompletionContext `json:"context"`
 }
 
@@ -62,16 +63,18 @@ type CompletionContext struct {
 	TriggerCharacter string `json:"TriggerCharacter"`
 }
 
-type CompletionResponse struct {
-	ResponseMessage
-	Result []CompletionItem `json:"result"`
-}
-
 type CompletionItem struct {
 	Label         string             `json:"label"`
 	Kind          CompletionItemKind `json:"kind"`
 	Detail        string             `json:"detail"`
 	Documentation string             `json:"documentation"`
+	TextEdit      *TextEdit          `json:"textEdit,omitempty"`
+}
+
+// replaces the text in a range, used instead of the label when inserting a completion
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
 }
 
 func generateCompletionItems(keywords []string) []CompletionItem {
@@ -89,28 +92,38 @@ func generateCompletionItems(keywords []string) []CompletionItem {
 	return result
 }
 
-func HandleCompletion(content []byte, logger *log.Logger) error {
+func HandleCompletion(ctx context.Context, conn *rpc.Conn, content []byte, logger *slog.Logger, state *ServerState) error {
 	var request CompletionRequest
 	if err := json.Unmarshal(content, &request); err != nil {
 		return err
 	}
 
-	logger.Printf("recieved completion with trigger %d on '%s'\n", request.Params.Context.TriggerKind, request.Params.Context.TriggerCharacter)
+	logger.Debug("received completion", "trigger", request.Params.Context.TriggerKind, "character", request.Params.Context.TriggerCharacter)
 
-	response := CompletionResponse{
-		ResponseMessage: ResponseMessage{
-			ID:  request.ID,
-			RPC: "2.0",
-		},
-		Result: generateCompletionItems(keywords),
+	// the client has moved on if the request was cancelled
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
-	encodedResponse, err := rpc.EncodeMessage(response)
-	if err != nil {
-		return err
+	// input action names are completed inside the strings passed to Input.is_action_pressed and friends
+	state.RLock()
+	document, ok := state.Files[request.Params.TextDocument.URI]
+	var source string
+	if ok {
+		source = document.Text
+	}
+	inputs := state.ProjectConfig.InputConfigs
+	state.RUnlock()
+
+	if ok && isProjectFile(request.Params.TextDocument.URI) {
+		return conn.Reply(request.ID, projectFileCompletionItems(source, request.Params.Position))
 	}
 
-	fmt.Print(encodedResponse)
+	if ok {
+		if _, found := actionArgumentAt(source, offsetAt(source, request.Params.Position)); found {
+			return conn.Reply(request.ID, generateActionCompletionItems(inputs))
+		}
+	}
 
-	return nil
+	return conn.Reply(request.ID, generateCompletionItems(keywords))
 }
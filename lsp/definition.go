@@ -0,0 +1,63 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"gdx/rpc"
+	"log/slog"
+)
+
+type DefinitionRequest struct {
+	RequestMessage
+	Params TextDocumentPositionParams `json:"params"`
+}
+
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// goes from a uid:// identifier to the file it belongs to, and from a property set in a .tres
+// file to the variable declaring it in the resource's script. Anything else has no definition
+// so the result is null
+func HandleDefinition(ctx context.Context, conn *rpc.Conn, content []byte, logger *slog.Logger, state *ServerState) error {
+	var request DefinitionRequest
+	if err := json.Unmarshal(content, &request); err != nil {
+		return err
+	}
+
+	logger.Debug("received definition", "uri", request.Params.TextDocument.URI, "line", request.Params.Position.Line, "character", request.Params.Position.Character)
+
+	state.RLock()
+	document, ok := state.Files[request.Params.TextDocument.URI]
+	var source string
+	if ok {
+		source = document.Text
+	}
+	uids := state.UIDs
+	workspacePath := state.WorkspacePath
+	state.RUnlock()
+
+	if !ok {
+		return conn.Reply(request.ID, nil)
+	}
+
+	var location *Location
+	if reference, found := uidAt(source, offsetAt(source, request.Params.Position)); found && uids != nil {
+		location, ok = uidDefinition(reference, uids, workspacePath)
+	} else if isResourceFile(request.Params.TextDocument.URI) {
+		location, ok = resourcePropertyDefinition(source, request.Params.Position, state)
+	} else {
+		ok = false
+	}
+
+	if !ok {
+		return conn.Reply(request.ID, nil)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return conn.Reply(request.ID, location)
+}
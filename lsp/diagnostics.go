@@ -2,19 +2,20 @@ package lsp
 
 import (
 	"errors"
-	"fmt"
+	"gdx/analysis"
 	"gdx/analysis/lexer"
+	"gdx/analysis/parser"
 	"gdx/rpc"
-	"log"
+	"log/slog"
 )
 
-type Serverity = int
+type Severity = int
 
 const (
-	SeverityError       Serverity = 1
-	SeverityWarning     Serverity = 2
-	SeverityInformation Serverity = 3
-	SeverityHint        Serverity = 4
+	SeverityError       Severity = 1
+	SeverityWarning     Severity = 2
+	SeverityInformation Severity = 3
+	SeverityHint        Severity = 4
 )
 
 type Position struct {
@@ -28,10 +29,10 @@ type Range struct {
 }
 
 type Diagnostic struct {
-	Range     Range     `json:"range"`
-	Serverity Serverity `json:"serverity"`
-	Source    string    `json:"source"`
-	Message   string    `json:"message"`
+	Range    Range    `json:"range"`
+	Severity Severity `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
 }
 
 type PublishDiagnosticParams struct {
@@ -39,66 +40,73 @@ type PublishDiagnosticParams struct {
 	Diagnostics []Diagnostic `json:"diagnostics"`
 }
 
-type PublishDiagnosticNotification struct {
-	Notification
-	Method string                  `json:"method"`
-	Params PublishDiagnosticParams `json:"params"`
-}
+func RunDiagnostics(conn *rpc.Conn, serverState *ServerState, logger *slog.Logger, documentURI string) error {
+	serverState.RLock()
+	document, ok := serverState.Files[documentURI]
+	var source string
+	if ok {
+		source = document.Text
+	}
+	project := serverState.ProjectConfig
+	uids := serverState.UIDs
+	serverState.RUnlock()
 
-func RunDiagnostics(serverState *ServerState, logger *log.Logger, documentURI string) error {
-	source, ok := serverState.Files[documentURI]
 	if !ok {
-		logger.Println("error: invalid file URI while running diagnostics")
+		logger.Error("invalid file URI while running diagnostics", "uri", documentURI)
 		return errors.New("invalid file URI")
 	}
 
-	logger.Printf("running diagnostics on '%s'\n", documentURI)
-
-	scanner := lexer.NewScanner(source)
-
-	_, err := scanner.ScanTokens()
+	logger.Debug("running diagnostics", "uri", documentURI)
 
-	var diagnostics []Diagnostic = make([]Diagnostic, 0)
-
-	if err != nil {
-		var lerr *lexer.LexicalError = err.(*lexer.LexicalError)
-
-		diagnostics = []Diagnostic{
-			{
-				Range: Range{
-					Start: Position{
-						Line:      uint(lerr.Line),
-						Character: uint(lerr.StartChar),
-					},
-					End: Position{
-						Line:      uint(lerr.Line),
-						Character: uint(lerr.EndChar),
-					},
-				},
-				Serverity: SeverityError,
-				Source:    "gdx",
-				Message:   lerr.Message,
-			},
-		}
+	var diagnostics []Diagnostic
+	switch {
+	case isProjectFile(documentURI):
+		diagnostics = projectFileDiagnostics(source)
+	case isResourceFile(documentURI):
+		diagnostics = resourceFileDiagnostics(source, serverState)
+	default:
+		diagnostics = scriptDiagnostics(source, &project)
 	}
 
+	diagnostics = append(diagnostics, uidDiagnostics(source, uids)...)
+
 	params := PublishDiagnosticParams{
 		URI:         documentURI,
 		Diagnostics: diagnostics,
 	}
 
-	payload := PublishDiagnosticNotification{
-		Method: "textDocument/publishDiagnostics",
-		Params: params,
+	return conn.Notify("textDocument/publishDiagnostics", params)
+
+}
+
+// checks a GDScript file for lexical and syntax errors, and for uses of the project that don't match project.godot
+func scriptDiagnostics(source string, project *analysis.GodotProjectFile) []Diagnostic {
+	scanner := lexer.NewScanner(source)
+
+	tokens, lexicalErrors := scanner.ScanTokens()
+	_, syntaxErrors := parser.NewParser(tokens).Parse()
+
+	var diagnostics []Diagnostic = make([]Diagnostic, 0)
+
+	for _, lerr := range lexicalErrors {
+		diagnostics = append(diagnostics, errorDiagnostic(source, lerr.Start, lerr.End, lerr.Message))
 	}
 
-	encodedPayload, err := rpc.EncodeMessage(payload)
-	if err != nil {
-		return err
+	for _, serr := range syntaxErrors {
+		diagnostics = append(diagnostics, errorDiagnostic(source, serr.Range.Start, serr.Range.End, serr.Message))
 	}
 
-	fmt.Print(encodedPayload)
+	diagnostics = append(diagnostics, actionDiagnostics(source, tokens, project)...)
 
-	return nil
+	return diagnostics
+}
 
+// reports a lexical or syntax error found while reading a document
+func errorDiagnostic(source string, start lexer.Position, end lexer.Position, message string) Diagnostic {
+	return Diagnostic{
+		Range:    toRange(source, start, end),
+		Severity: SeverityError,
+		Source:   "gdx",
+		Message:  message,
+	}
 }
@@ -0,0 +1,244 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gdx/rpc"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// handles a single message. Requests are given a context that is cancelled
+// when the client sends $/cancelRequest for them. Request handlers reply
+// themselves, if they return an error the dispatcher replies with it instead,
+// using the error as is if it's a *ResponseError
+type HandlerFunc func(ctx context.Context, conn *rpc.Conn, method string, content []byte, logger *slog.Logger, state *ServerState) error
+
+type CancelRequestNotification struct {
+	Notification
+	Params CancelParams `json:"params"`
+}
+
+type CancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// requests that change the state of the whole session. These run in order
+// like notifications so the messages after them see the new state
+var sequentialRequests = map[string]bool{
+	"initialize": true,
+	"shutdown":   true,
+}
+
+// runs handlers for incoming messages. Requests run concurrently on their own
+// goroutines while notifications, which may change documents, run in the
+// order they arrive before any later message is read
+type Dispatcher struct {
+	handler HandlerFunc
+	conn    *rpc.Conn
+	logger  *slog.Logger
+	state   *ServerState
+
+	mu sync.Mutex
+	// running requests keyed by their ID
+	pending map[string]context.CancelFunc
+	running sync.WaitGroup
+
+	// cancelled when the session ends, handlers are run with a context derived from it
+	session context.Context
+	stop    context.CancelFunc
+	// work started by handlers with Background
+	background sync.WaitGroup
+}
+
+type dispatcherKey struct{}
+
+func NewDispatcher(handler HandlerFunc, conn *rpc.Conn, logger *slog.Logger, state *ServerState) *Dispatcher {
+	d := &Dispatcher{
+		handler: handler,
+		conn:    conn,
+		logger:  logger,
+		state:   state,
+		pending: make(map[string]context.CancelFunc),
+	}
+	d.session, d.stop = context.WithCancel(context.WithValue(context.Background(), dispatcherKey{}, d))
+
+	return d
+}
+
+// handles a request or notification, responses should be passed to the connection instead
+func (d *Dispatcher) Dispatch(message rpc.BaseMessage, content []byte) {
+	if message.Method == "$/cancelRequest" {
+		d.cancel(content)
+		return
+	}
+
+	if err := admit(message.Method, d.state); err != nil {
+		if message.IsRequest() {
+			d.logger.Warn("rejected request", "id", string(message.ID), "method", message.Method, "error", err)
+			if err := d.conn.ReplyError(message.ID, err); err != nil {
+				d.logger.Error("unable to reply to request", "id", string(message.ID), "error", err)
+			}
+		} else {
+			d.logger.Warn("rejected notification", "method", message.Method, "error", err)
+		}
+		return
+	}
+
+	if !message.IsRequest() {
+		err := d.handle(d.session, message, content)
+
+		// clients send notifications the server may not support, such as $/progress
+		var responseError *ResponseError
+		if errors.As(err, &responseError) && responseError.Code == ErrCodeMethodNotFound {
+			d.logger.Debug("ignoring unsupported notification", "method", message.Method)
+		} else if err != nil {
+			d.logger.Error("error while handling notification", "method", message.Method, "error", err)
+		}
+		return
+	}
+
+	// the content may be reused by the reader once the next message is read
+	content = bytes.Clone(content)
+
+	id := string(message.ID)
+	ctx, cancel := context.WithCancel(d.session)
+
+	d.mu.Lock()
+	d.pending[id] = cancel
+	d.mu.Unlock()
+
+	if sequentialRequests[message.Method] {
+		d.run(ctx, message, content)
+		return
+	}
+
+	d.running.Add(1)
+	go func() {
+		defer d.running.Done()
+		d.run(ctx, message, content)
+	}()
+}
+
+// runs a request's handler, replying with the error it returns if there is one
+func (d *Dispatcher) run(ctx context.Context, message rpc.BaseMessage, content []byte) {
+	id := string(message.ID)
+	defer d.finish(id)
+
+	err := d.handle(ctx, message, content)
+	if err == nil {
+		return
+	}
+
+	d.logger.Error("error while handling request", "id", id, "method", message.Method, "error", err)
+
+	var responseError *ResponseError
+	switch {
+	case errors.As(err, &responseError):
+	case errors.Is(err, context.Canceled):
+		responseError = &ResponseError{Code: ErrCodeRequestCancelled, Message: "request was cancelled"}
+	default:
+		responseError = &ResponseError{Code: ErrCodeInternalError, Message: err.Error()}
+	}
+
+	if err := d.conn.ReplyError(message.ID, responseError); err != nil {
+		d.logger.Error("unable to reply to request", "id", id, "error", err)
+	}
+}
+
+// calls the handler, turning a panic into an error so a message the handler can't
+// cope with doesn't take down the server and every other session with it
+func (d *Dispatcher) handle(ctx context.Context, message rpc.BaseMessage, content []byte) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			d.logger.Error("panic while handling message", "method", message.Method, "panic", recovered, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic while handling %s: %v", message.Method, recovered)
+		}
+	}()
+
+	return d.handler(ctx, d.conn, message.Method, content, d.logger, d.state)
+}
+
+// checks whether a message can be handled in the session's current state.
+// This runs in the order messages arrive, so a request sent after shutdown is
+// rejected even if requests sent before it are still running
+func admit(method string, state *ServerState) *ResponseError {
+	// exit is always handled, the session's exit code depends on whether shutdown was requested first
+	if method == "exit" {
+		return nil
+	}
+
+	state.RLock()
+	defer state.RUnlock()
+
+	if state.Shutdown {
+		return &ResponseError{Code: ErrCodeInvalidRequest, Message: "server is shutdown"}
+	}
+
+	if !state.Initialized && method != "initialize" {
+		return &ResponseError{Code: ErrCodeServerNotInitialized, Message: "server has not been initialized"}
+	}
+
+	return nil
+}
+
+// waits for all running requests to finish
+func (d *Dispatcher) Wait() {
+	d.running.Wait()
+}
+
+// ends the session once the running requests have replied. Work started with Background is
+// cancelled, since the client can no longer answer it, and waited for
+func (d *Dispatcher) Close() {
+	d.running.Wait()
+	d.stop()
+	d.background.Wait()
+}
+
+// runs work that outlives the message being handled, such as a request sent to the client
+// while its response still has to be read. fn is given the session's context rather than
+// ctx, so it isn't cancelled when a request finishes but is when the session ends. Outside
+// of a dispatcher, such as in tests, fn runs with ctx
+func Background(ctx context.Context, fn func(ctx context.Context)) {
+	d, ok := ctx.Value(dispatcherKey{}).(*Dispatcher)
+	if !ok {
+		go fn(ctx)
+		return
+	}
+
+	d.background.Add(1)
+	go func() {
+		defer d.background.Done()
+		fn(d.session)
+	}()
+}
+
+func (d *Dispatcher) cancel(content []byte) {
+	var notification CancelRequestNotification
+	if err := json.Unmarshal(content, &notification); err != nil {
+		d.logger.Warn("unable to read $/cancelRequest", "error", err)
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// requests that already finished can't be cancelled
+	if cancel, ok := d.pending[string(notification.Params.ID)]; ok {
+		cancel()
+	}
+}
+
+func (d *Dispatcher) finish(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if cancel, ok := d.pending[id]; ok {
+		cancel()
+		delete(d.pending, id)
+	}
+}
@@ -0,0 +1,249 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"gdx/rpc"
+)
+
+// a writer that can be read from while handlers are still writing to it
+type syncBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buffer.String()
+}
+
+func dispatch(dispatcher *Dispatcher, content string) {
+	message, err := rpc.ParseMessage([]byte(content))
+	if err != nil {
+		panic(err)
+	}
+
+	dispatcher.Dispatch(message, []byte(content))
+}
+
+func TestDispatcherCancelRequest(t *testing.T) {
+	output := &syncBuffer{}
+	state := &ServerState{Initialized: true, Files: make(map[string]*Document)}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	notifications := make([]string, 0)
+
+	handler := func(ctx context.Context, conn *rpc.Conn, method string, content []byte, logger *slog.Logger, state *ServerState) error {
+		switch method {
+		case "slow":
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		case "fast":
+			<-release
+			return conn.Reply(json.RawMessage(`"fast"`), nil)
+		default:
+			notifications = append(notifications, method)
+			return nil
+		}
+	}
+
+	dispatcher := NewDispatcher(handler, rpc.NewConn(output), slog.New(slog.NewTextHandler(io.Discard, nil)), state)
+
+	dispatch(dispatcher, `{"id":1,"method":"slow"}`)
+	<-started
+
+	// a slow request doesn't hold up the messages after it
+	dispatch(dispatcher, `{"id":"fast","method":"fast"}`)
+	dispatch(dispatcher, `{"method":"first"}`)
+	dispatch(dispatcher, `{"method":"second"}`)
+	close(release)
+
+	dispatch(dispatcher, `{"method":"$/cancelRequest","params":{"id":1}}`)
+	dispatcher.Wait()
+
+	if fmt.Sprint(notifications) != "[first second]" {
+		t.Errorf("expected notifications to run in order, got %v", notifications)
+	}
+
+	expected := `{"jsonrpc":"2.0","id":1,"error":{"code":-32800,"message":"request was cancelled"}}`
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expected a RequestCancelled response, got %s", output.String())
+	}
+
+	if !strings.Contains(output.String(), `{"jsonrpc":"2.0","id":"fast","result":null}`) {
+		t.Errorf("expected a response to the fast request, got %s", output.String())
+	}
+}
+
+func TestDispatcherErrorReplies(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		panics   bool
+		content  string
+		expected string
+	}{
+		{
+			name:     "Response error",
+			err:      &ResponseError{Code: ErrCodeMethodNotFound, Message: "method test is not supported"},
+			content:  `{"jsonrpc":"2.0","id":"a","method":"test"}`,
+			expected: `{"jsonrpc":"2.0","id":"a","error":{"code":-32601,"message":"method test is not supported"}}`,
+		},
+		{
+			name:     "Other error",
+			err:      errors.New("something broke"),
+			content:  `{"jsonrpc":"2.0","id":4,"method":"test"}`,
+			expected: `{"jsonrpc":"2.0","id":4,"error":{"code":-32603,"message":"something broke"}}`,
+		},
+		{
+			name:     "Notification",
+			err:      errors.New("something broke"),
+			content:  `{"jsonrpc":"2.0","method":"test"}`,
+			expected: "",
+		},
+		{
+			name:     "Panic",
+			err:      errors.New("slice bounds out of range"),
+			panics:   true,
+			content:  `{"jsonrpc":"2.0","id":5,"method":"test"}`,
+			expected: `{"jsonrpc":"2.0","id":5,"error":{"code":-32603,"message":"panic while handling test: slice bounds out of range"}}`,
+		},
+		{
+			name:     "Panic in a notification",
+			err:      errors.New("slice bounds out of range"),
+			panics:   true,
+			content:  `{"jsonrpc":"2.0","method":"test"}`,
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &syncBuffer{}
+			handler := func(ctx context.Context, conn *rpc.Conn, method string, content []byte, logger *slog.Logger, state *ServerState) error {
+				if test.panics {
+					panic(test.err)
+				}
+				return test.err
+			}
+
+			dispatcher := NewDispatcher(handler, rpc.NewConn(output), slog.New(slog.NewTextHandler(io.Discard, nil)), &ServerState{Initialized: true})
+			dispatch(dispatcher, test.content)
+			dispatcher.Wait()
+
+			expected := ""
+			if test.expected != "" {
+				expected = fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(test.expected), test.expected)
+			}
+
+			if output.String() != expected {
+				t.Errorf("expected %q, got %q", expected, output.String())
+			}
+		})
+	}
+}
+
+func TestDispatcherSessionState(t *testing.T) {
+	tests := []struct {
+		name     string
+		state    *ServerState
+		content  string
+		expected string
+	}{
+		{
+			name:     "Before initialize",
+			state:    &ServerState{},
+			content:  `{"jsonrpc":"2.0","id":1,"method":"textDocument/completion"}`,
+			expected: `{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"server has not been initialized"}}`,
+		},
+		{
+			name:     "After shutdown",
+			state:    &ServerState{Initialized: true, Shutdown: true},
+			content:  `{"jsonrpc":"2.0","id":"b","method":"textDocument/completion"}`,
+			expected: `{"jsonrpc":"2.0","id":"b","error":{"code":-32600,"message":"server is shutdown"}}`,
+		},
+		{
+			name:     "Exit after shutdown",
+			state:    &ServerState{Initialized: true, Shutdown: true},
+			content:  `{"jsonrpc":"2.0","method":"exit"}`,
+			expected: "handled",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := &syncBuffer{}
+			handler := func(ctx context.Context, conn *rpc.Conn, method string, content []byte, logger *slog.Logger, state *ServerState) error {
+				output.Write([]byte("handled"))
+				return nil
+			}
+
+			dispatcher := NewDispatcher(handler, rpc.NewConn(output), slog.New(slog.NewTextHandler(io.Discard, nil)), test.state)
+			dispatch(dispatcher, test.content)
+			dispatcher.Wait()
+
+			expected := test.expected
+			if strings.HasPrefix(expected, "{") {
+				expected = fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(expected), expected)
+			}
+
+			if output.String() != expected {
+				t.Errorf("expected %q, got %q", expected, output.String())
+			}
+		})
+	}
+}
+
+func TestDispatcherCloseCancelsBackground(t *testing.T) {
+	started := make(chan struct{})
+	done := make(chan struct{})
+	var result error
+
+	handler := func(ctx context.Context, conn *rpc.Conn, method string, content []byte, logger *slog.Logger, state *ServerState) error {
+		Background(ctx, func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+			result = ctx.Err()
+			close(done)
+		})
+		return nil
+	}
+
+	state := &ServerState{Initialized: true}
+	dispatcher := NewDispatcher(handler, rpc.NewConn(io.Discard), slog.New(slog.NewTextHandler(io.Discard, nil)), state)
+
+	// the request finishing doesn't cancel the work it started
+	dispatch(dispatcher, `{"id":1,"method":"start"}`)
+	dispatcher.Wait()
+	<-started
+
+	select {
+	case <-done:
+		t.Fatal("expected the background work to keep running after the request finished")
+	default:
+	}
+
+	dispatcher.Close()
+
+	if !errors.Is(result, context.Canceled) {
+		t.Errorf("expected the background work to be cancelled when the session ends, got %v", result)
+	}
+}
@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+type TextDocumentSyncKind = int
+
+const (
+	TextDocumentSyncKindNone        TextDocumentSyncKind = 0
+	TextDocumentSyncKindFull        TextDocumentSyncKind = 1
+	TextDocumentSyncKindIncremental TextDocumentSyncKind = 2
+)
+
+// an open text document along with the version the client last sent for it
+type Document struct {
+	Version int
+	Text    string
+}
+
+// applies a content change to the document. Changes without a range replace the whole text
+func (d *Document) ApplyChange(change TextDocumentContentChangeEvent) error {
+	if change.Range == nil {
+		d.Text = change.Text
+		return nil
+	}
+
+	start := offsetAt(d.Text, change.Range.Start)
+	end := offsetAt(d.Text, change.Range.End)
+	if end < start {
+		return fmt.Errorf("invalid change range, end %+v is before start %+v", change.Range.End, change.Range.Start)
+	}
+
+	d.Text = d.Text[:start] + change.Text + d.Text[end:]
+
+	return nil
+}
+
+// converts an LSP position into a byte offset in the text. Positions past the end of a line
+// are clamped to the end of that line and positions past the last line to the end of the text
+func offsetAt(text string, position Position) int {
+	offset := 0
+	for line := uint(0); line < position.Line; line++ {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next == -1 {
+			return len(text)
+		}
+
+		offset += next + 1
+	}
+
+	lineEnd := strings.IndexByte(text[offset:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(text)
+	} else {
+		lineEnd += offset
+	}
+
+	// the character is counted in UTF-16 code units
+	units := uint(0)
+	for offset < lineEnd {
+		char, size := utf8.DecodeRuneInString(text[offset:lineEnd])
+
+		width := uint(1)
+		if char >= 0x10000 {
+			width = 2
+		}
+
+		if units+width > position.Character {
+			break
+		}
+
+		units += width
+		offset += size
+	}
+
+	return offset
+}
@@ -0,0 +1,121 @@
+package lsp
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"gdx/rpc"
+)
+
+func TestDocumentApplyChange(t *testing.T) {
+	source := "var a = 1\nvar größe = \"😀\" + b\n"
+
+	tests := []struct {
+		name     string
+		changes  []TextDocumentContentChangeEvent
+		expected string
+	}{
+		{
+			name:     "Full text",
+			changes:  []TextDocumentContentChangeEvent{{Text: "pass"}},
+			expected: "pass",
+		},
+		{
+			name: "Insert",
+			changes: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{Line: 0, Character: 5}, End: Position{Line: 0, Character: 5}}, Text: "bc"},
+			},
+			expected: "var abc = 1\nvar größe = \"😀\" + b\n",
+		},
+		{
+			name: "Replace after surrogate pair",
+			changes: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{Line: 1, Character: 19}, End: Position{Line: 1, Character: 20}}, Text: "c"},
+			},
+			expected: "var a = 1\nvar größe = \"😀\" + c\n",
+		},
+		{
+			name: "Delete across lines",
+			changes: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{Line: 0, Character: 9}, End: Position{Line: 1, Character: 10}}, Text: ""},
+			},
+			expected: "var a = 1= \"😀\" + b\n",
+		},
+		{
+			name: "Changes applied in order",
+			changes: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 3}}, Text: "const"},
+				{Range: &Range{Start: Position{Line: 0, Character: 6}, End: Position{Line: 0, Character: 7}}, Text: "A"},
+			},
+			expected: "const A = 1\nvar größe = \"😀\" + b\n",
+		},
+		{
+			name: "Past the end of a line",
+			changes: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{Line: 0, Character: 100}, End: Position{Line: 0, Character: 100}}, Text: "0"},
+			},
+			expected: "var a = 10\nvar größe = \"😀\" + b\n",
+		},
+		{
+			name: "Past the end of the document",
+			changes: []TextDocumentContentChangeEvent{
+				{Range: &Range{Start: Position{Line: 5, Character: 0}, End: Position{Line: 5, Character: 0}}, Text: "pass"},
+			},
+			expected: "var a = 1\nvar größe = \"😀\" + b\npass",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document := &Document{Text: source}
+
+			for _, change := range test.changes {
+				if err := document.ApplyChange(change); err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+			}
+
+			if document.Text != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, document.Text)
+			}
+		})
+	}
+}
+
+func TestHandleTextDocumentChangeWithoutChanges(t *testing.T) {
+	state := &ServerState{
+		Files: map[string]*Document{"file:///a.gd": {Version: 1, Text: "pass"}},
+	}
+
+	content := []byte(`{"method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.gd","version":2},"contentChanges":[]}}`)
+
+	if err := HandleTextDocumentChange(rpc.NewConn(io.Discard), content, slog.New(slog.NewTextHandler(io.Discard, nil)), state); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	document := state.Files["file:///a.gd"]
+	if document.Version != 2 || document.Text != "pass" {
+		t.Errorf("expected version 2 with unchanged text, got %+v", document)
+	}
+}
+
+func TestHandleTextDocumentChangeFailingPartway(t *testing.T) {
+	state := &ServerState{
+		Files: map[string]*Document{"file:///a.gd": {Version: 1, Text: "var a = 1\n"}},
+	}
+
+	// the first change is valid but the second ends before it starts
+	content := []byte(`{"method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///a.gd","version":2},"contentChanges":[` +
+		`{"range":{"start":{"line":0,"character":4},"end":{"line":0,"character":5}},"text":"b"},` +
+		`{"range":{"start":{"line":0,"character":8},"end":{"line":0,"character":4}},"text":"2"}]}}`)
+
+	if err := HandleTextDocumentChange(rpc.NewConn(io.Discard), content, slog.New(slog.NewTextHandler(io.Discard, nil)), state); err == nil {
+		t.Fatal("expected an error for the invalid range")
+	}
+
+	document := state.Files["file:///a.gd"]
+	if document.Version != 1 || document.Text != "var a = 1\n" {
+		t.Errorf("expected the document to be left as it was, got %+v", document)
+	}
+}
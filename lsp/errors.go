@@ -1,15 +1,21 @@
 package lsp
 
+import "gdx/rpc"
+
 const (
 	ErrCodeParseError     int = -32700
 	ErrCodeInvalidRequest int = -32600
 	ErrCodeMethodNotFound int = -32601
 	ErrCodeInvalidParams  int = -32602
 	ErrCodeInternalError  int = -32603
+
+	ErrCodeServerNotInitialized int = -32002
+	ErrCodeUnknownErrorCode     int = -32001
+
+	ErrCodeRequestFailed    int = -32803
+	ErrCodeServerCancelled  int = -32802
+	ErrCodeContentModified  int = -32801
+	ErrCodeRequestCancelled int = -32800
 )
 
-type ResponseError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    any    `json:"data,omitempty"`
-}
+type ResponseError = rpc.ResponseError
@@ -0,0 +1,210 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gdx/analysis"
+	"gdx/rpc"
+	"gdx/version"
+)
+
+// splits everything written to a connection back into JSON messages
+func messages(t *testing.T, output *bytes.Buffer) []string {
+	t.Helper()
+
+	result := make([]string, 0)
+	data := output.Bytes()
+	for len(data) > 0 {
+		advance, token, err := rpc.Split(data, false)
+		if err != nil || token == nil {
+			t.Fatalf("unable to split message: %v", err)
+		}
+
+		_, content, _ := bytes.Cut(token, []byte("\r\n\r\n"))
+		result = append(result, string(content))
+		data = data[advance:]
+	}
+
+	return result
+}
+
+// a project with two input actions
+func project() analysis.GodotProjectFile {
+	return analysis.GodotProjectFile{
+		InputConfigs: []analysis.InputConfig{
+			{
+				Name:       "forward",
+				Deadzone:   0.2,
+				Keybinding: "W, Joypad Axis 1 -",
+				Events: []analysis.InputEvent{
+					analysis.InputEventKey{PhysicalKeycode: 87},
+					analysis.InputEventJoypadMotion{Axis: 1, AxisValue: -1},
+				},
+			},
+			{Name: "jump", Deadzone: 0.5},
+		},
+	}
+}
+
+func TestHandlerOutput(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name     string
+		handle   func(conn *rpc.Conn, state *ServerState) error
+		expected []string
+	}{
+		{
+			name: "Initialize",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				content := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootPath":"/game"}}`
+				return HandleInitialize(conn, []byte(content), logger, state)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":1,"result":{"serverInfo":{"name":"gdx","version":"` + version.Version + `"},"capabilities":{"textDocumentSync":2,"completionProvider":{"triggerCharacters":["\"","["]},"hoverProvider":true,"definitionProvider":true}}}`,
+			},
+		},
+		{
+			name: "Completion",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				content := `{"jsonrpc":"2.0","id":7,"method":"textDocument/completion","params":{"context":{"triggerKind":1}}}`
+				return HandleCompletion(context.Background(), conn, []byte(content), logger, state)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":7,"result":[` + strings.Join([]string{
+					`{"label":"if","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"elif","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"else","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"for","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"while","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"match","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"when","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+					`{"label":"break","kind":14,"detail":"a language keyword","documentation":"a language keyword"}`,
+				}, ",") + `]}`,
+			},
+		},
+		{
+			name: "Completion of input actions",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				state.Files["file:///player.gd"] = &Document{Text: "func _process(delta):\n\tif Input.is_action_pressed(\"f\n"}
+				state.ProjectConfig = project()
+				content := `{"jsonrpc":"2.0","id":8,"method":"textDocument/completion","params":{"textDocument":{"uri":"file:///player.gd"},"position":{"line":1,"character":30}}}`
+				return HandleCompletion(context.Background(), conn, []byte(content), logger, state)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":8,"result":[` +
+					`{"label":"forward","kind":21,"detail":"W, Joypad Axis 1 -","documentation":"input action with a deadzone of 0.2"},` +
+					`{"label":"jump","kind":21,"detail":"","documentation":"input action with a deadzone of 0.5"}]}`,
+			},
+		},
+		{
+			name: "Hover over an input action",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				state.Files["file:///player.gd"] = &Document{Text: "var moving = Input.get_action_strength(&\"forward\")\n"}
+				state.ProjectConfig = project()
+				content := `{"jsonrpc":"2.0","id":9,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///player.gd"},"position":{"line":0,"character":40}}}`
+				return HandleHover(context.Background(), conn, []byte(content), logger, state)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":9,"result":{"contents":{"kind":"markdown","value":"**forward** input action\n\nDeadzone: 0.2\n\n- W\n- Joypad Axis 1 -\n"},` +
+					`"range":{"start":{"line":0,"character":39},"end":{"line":0,"character":49}}}}`,
+			},
+		},
+		{
+			name: "Hover elsewhere",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				state.Files["file:///player.gd"] = &Document{Text: "var moving = Input.get_action_strength(\"forward\")\n"}
+				state.ProjectConfig = project()
+				content := `{"jsonrpc":"2.0","id":10,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///player.gd"},"position":{"line":0,"character":20}}}`
+				return HandleHover(context.Background(), conn, []byte(content), logger, state)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","id":10,"result":null}`,
+			},
+		},
+		{
+			name: "Open publishes diagnostics",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				content := `{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.gd","languageId":"gdscript","version":1,"text":"var = 1"}}}`
+				return HandleTextDocumentOpen(conn, []byte(content), logger, state)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///a.gd","diagnostics":[` +
+					`{"range":{"start":{"line":0,"character":4},"end":{"line":0,"character":5}},"severity":1,"source":"gdx","message":"expected a variable name after 'var', found '='"}]}}`,
+			},
+		},
+		{
+			name: "Trace off",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				return TraceMessage(conn, state, false, []byte(`{"jsonrpc":"2.0","method":"initialized"}`))
+			},
+			expected: []string{},
+		},
+		{
+			name: "Trace messages",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				if err := HandleSetTrace([]byte(`{"method":"$/setTrace","params":{"value":"messages"}}`), logger, state); err != nil {
+					return err
+				}
+
+				conn.OnSend(func(content []byte) { TraceMessage(conn, state, true, content) })
+				if err := TraceMessage(conn, state, false, []byte(`{"jsonrpc":"2.0","id":"a","method":"shutdown"}`)); err != nil {
+					return err
+				}
+
+				return conn.Reply([]byte(`"a"`), nil)
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","method":"$/logTrace","params":{"message":"Received request 'shutdown - (\"a\")'."}}`,
+				`{"jsonrpc":"2.0","method":"$/logTrace","params":{"message":"Sending response '(\"a\")'."}}`,
+				`{"jsonrpc":"2.0","id":"a","result":null}`,
+			},
+		},
+		{
+			name: "Trace verbose",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				state.Trace = TraceVerbose
+				return TraceMessage(conn, state, false, []byte(`{"jsonrpc":"2.0","method":"initialized"}`))
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","method":"$/logTrace","params":{"message":"Received notification 'initialized'.","verbose":"{\"jsonrpc\":\"2.0\",\"method\":\"initialized\"}"}}`,
+			},
+		},
+		{
+			name: "Logs forwarded to the client",
+			handle: func(conn *rpc.Conn, state *ServerState) error {
+				clientLogger := slog.New(NewClientLogHandler(conn, slog.LevelInfo)).With("session", 1)
+				clientLogger.Debug("not sent")
+				clientLogger.WithGroup("document").Warn("document changed", "uri", "file:///a.gd")
+				clientLogger.Error("unable to parse", "error", errors.New("unexpected EOF"))
+				return nil
+			},
+			expected: []string{
+				`{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":2,"message":"document changed session=1 document.uri=file:///a.gd"}}`,
+				`{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":1,"message":"unable to parse session=1 error=unexpected EOF"}}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			state := &ServerState{Files: make(map[string]*Document)}
+
+			if err := test.handle(rpc.NewConn(&output), state); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual := messages(t, &output)
+			if strings.Join(actual, "\n") != strings.Join(test.expected, "\n") {
+				t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(test.expected, "\n"), strings.Join(actual, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"gdx/rpc"
+	"log/slog"
+)
+
+type HoverRequest struct {
+	RequestMessage
+	Params TextDocumentPositionParams `json:"params"`
+}
+
+type MarkupContent struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// describes the input action under the cursor, the setting under it in project.godot, or
+// the file a uid:// identifier belongs to. Anything else has no hover so the result is null
+func HandleHover(ctx context.Context, conn *rpc.Conn, content []byte, logger *slog.Logger, state *ServerState) error {
+	var request HoverRequest
+	if err := json.Unmarshal(content, &request); err != nil {
+		return err
+	}
+
+	logger.Debug("received hover", "uri", request.Params.TextDocument.URI, "line", request.Params.Position.Line, "character", request.Params.Position.Character)
+
+	state.RLock()
+	document, ok := state.Files[request.Params.TextDocument.URI]
+	var source string
+	if ok {
+		source = document.Text
+	}
+	uids := state.UIDs
+	state.RUnlock()
+
+	if !ok {
+		return conn.Reply(request.ID, nil)
+	}
+
+	if reference, ok := uidAt(source, offsetAt(source, request.Params.Position)); ok && uids != nil {
+		return conn.Reply(request.ID, uidHover(source, reference, uids))
+	}
+
+	if isProjectFile(request.Params.TextDocument.URI) {
+		return conn.Reply(request.ID, projectFileHover(source, request.Params.Position))
+	}
+
+	// the position is on a character, which is inside the token when the position after it is
+	argument, ok := actionArgumentAt(source, offsetAt(source, request.Params.Position)+1)
+	if !ok {
+		return conn.Reply(request.ID, nil)
+	}
+
+	state.RLock()
+	input, ok := state.ProjectConfig.InputConfig(argument.Name)
+	state.RUnlock()
+
+	if !ok {
+		return conn.Reply(request.ID, nil)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	hoverRange := toRange(source, argument.Start, argument.End)
+
+	return conn.Reply(request.ID, Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: describeAction(input)},
+		Range:    &hoverRange,
+	})
+}
@@ -2,9 +2,8 @@ package lsp
 
 import (
 	"encoding/json"
-	"fmt"
 	"gdx/rpc"
-	"log"
+	"log/slog"
 
 	"gdx/version"
 )
@@ -24,12 +23,18 @@ type InitializeRequestParams struct {
 		Name    string `json:"name"`
 		Version string `json:"version,omitempty"`
 	} `json:"clientInfo"`
-	RootPath string `json:"rootPath"`
+	RootPath     string             `json:"rootPath"`
+	Capabilities ClientCapabilities `json:"capabilities"`
+	Trace        TraceValue         `json:"trace,omitempty"`
 }
 
-type InitializeResponse struct {
-	ResponseMessage
-	Result InitializeResult `json:"result"`
+// the features the client supports, only the ones gdx uses are decoded
+type ClientCapabilities struct {
+	Workspace struct {
+		DidChangeWatchedFiles struct {
+			DynamicRegistration bool `json:"dynamicRegistration"`
+		} `json:"didChangeWatchedFiles"`
+	} `json:"workspace"`
 }
 
 type InitializeResult struct {
@@ -43,50 +48,54 @@ type ServerInfo struct {
 }
 
 type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters,omitempty"`
 }
 
 type ServerCapabilities struct {
 	TextDocumentSync   int               `json:"textDocumentSync"`
 	CompletionProvider CompletionOptions `json:"completionProvider"`
+	HoverProvider      bool              `json:"hoverProvider"`
+	DefinitionProvider bool              `json:"definitionProvider"`
 }
 
-func HandleInitialize(content []byte, logger *log.Logger, state *ServerState) error {
+func HandleInitialize(conn *rpc.Conn, content []byte, logger *slog.Logger, state *ServerState) error {
 	var request InitializeRequest
 	if err := json.Unmarshal(content, &request); err != nil {
 		return err
 	}
 
-	logger.Printf(
-		"connected to client %s, version %s, workspace %s\n",
-		request.Params.ClientInfo.Name,
-		request.Params.ClientInfo.Version,
-		request.Params.RootPath,
+	logger.Info(
+		"connected to client",
+		"client", request.Params.ClientInfo.Name,
+		"version", request.Params.ClientInfo.Version,
+		"workspace", request.Params.RootPath,
 	)
 
+	state.Lock()
+	state.Initialized = true
 	state.WorkspacePath = request.Params.RootPath
+	state.ClientCapabilities = request.Params.Capabilities
+	if request.Params.Trace != "" {
+		state.Trace = request.Params.Trace
+	}
+	state.Unlock()
 
-	var response InitializeResponse = InitializeResponse{
-		Result: InitializeResult{
-			ServerInfo: ServerInfo{
-				Name:    ServerName,
-				Version: version.Version,
-			},
-			Capabilities: ServerCapabilities{
-				TextDocumentSync:   1,
-				CompletionProvider: CompletionOptions{},
-			},
+	result := InitializeResult{
+		ServerInfo: ServerInfo{
+			Name:    ServerName,
+			Version: version.Version,
 		},
-		ResponseMessage: ResponseMessage{
-			RPC: "2.0",
-			ID:  request.ID,
+		Capabilities: ServerCapabilities{
+			TextDocumentSync: TextDocumentSyncKindIncremental,
+			CompletionProvider: CompletionOptions{
+				// opening a string starts completing input action names and
+				// opening a tag in project.godot starts completing sections
+				TriggerCharacters: []string{`"`, "["},
+			},
+			HoverProvider:      true,
+			DefinitionProvider: true,
 		},
 	}
-	msg, err := rpc.EncodeMessage(response)
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("%s", msg)
 
-	return nil
+	return conn.Reply(request.ID, result)
 }
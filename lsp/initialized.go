@@ -1,38 +1,154 @@
 package lsp
 
 import (
+	"context"
 	"gdx/analysis"
-	"log"
+	"gdx/rpc"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type Registration struct {
-	Id     string `json:"id"`
-	Method string `json:"method"`
+	Id              string `json:"id"`
+	Method          string `json:"method"`
+	RegisterOptions any    `json:"registerOptions,omitempty"`
 }
 
 type RegistrationParams struct {
 	Registrations []Registration `json:"registrations"`
 }
 
-func HandleInitialized(logger *log.Logger, state *ServerState) error {
-	workspacePath := state.WorkspacePath
-	projectFilePath := filepath.Join(workspacePath, "project.godot")
+type FileSystemWatcher struct {
+	GlobPattern string `json:"globPattern"`
+}
 
-	data, err := os.ReadFile(projectFilePath)
-	if err != nil {
-		return err
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+// how long to wait for the client to accept a registration
+const registrationTimeout = 30 * time.Second
+
+func HandleInitialized(ctx context.Context, conn *rpc.Conn, logger *slog.Logger, state *ServerState) error {
+	state.RLock()
+	watchFiles := state.ClientCapabilities.Workspace.DidChangeWatchedFiles.DynamicRegistration
+	state.RUnlock()
+
+	// the client's response is read by the goroutine running this notification,
+	// so the registration has to be sent from another one
+	if watchFiles {
+		Background(ctx, func(ctx context.Context) {
+			registerProjectWatcher(ctx, conn, logger)
+		})
+	}
+
+	// the index is needed first to check the UIDs in project.godot
+	loadUIDs(logger, state)
+	loadClasses(logger, state)
+
+	_, err := loadProject(conn, logger, state, false)
+	return err
+}
+
+// asks the client to send workspace/didChangeWatchedFiles when project.godot, a script or a file with UIDs changes
+func registerProjectWatcher(ctx context.Context, conn *rpc.Conn, logger *slog.Logger) {
+	ctx, cancel := context.WithTimeout(ctx, registrationTimeout)
+	defer cancel()
+
+	params := RegistrationParams{
+		Registrations: []Registration{
+			{
+				Id:     "project-files",
+				Method: "workspace/didChangeWatchedFiles",
+				RegisterOptions: DidChangeWatchedFilesRegistrationOptions{
+					Watchers: []FileSystemWatcher{
+						{GlobPattern: "**/project.godot"},
+						{GlobPattern: "**/*.gd"},
+						{GlobPattern: "**/*.uid"},
+						{GlobPattern: "**/*.import"},
+						{GlobPattern: "**/*.tscn"},
+						{GlobPattern: "**/*.tres"},
+					},
+				},
+			},
+		},
 	}
 
-	projectConfig, err := analysis.ParseGodotProjectFile(data)
+	if err := conn.Call(ctx, "client/registerCapability", params, nil); err != nil {
+		logger.Warn("unable to watch project files for changes", "error", err)
+		return
+	}
+
+	logger.Debug("watching project files for changes")
+}
+
+// the path of the workspace's project.godot
+func projectFilePath(state *ServerState) string {
+	state.RLock()
+	defer state.RUnlock()
+
+	return filepath.Join(state.WorkspacePath, "project.godot")
+}
+
+// reads project.godot and replaces the project model. Problems in project.godot are shown
+// even if it isn't open, otherwise the editor wouldn't show why the project couldn't be loaded.
+// When reloading they are published even if there are none, to clear problems that were fixed
+func loadProject(conn *rpc.Conn, logger *slog.Logger, state *ServerState, reload bool) (*analysis.GodotProjectFile, error) {
+	filename := projectFilePath(state)
+
+	data, err := os.ReadFile(filename)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	state.RLock()
+	uids := state.UIDs
+	state.RUnlock()
+
+	// an open project.godot has diagnostics for the editor's text instead
+	diagnostics := append(projectFileDiagnostics(string(data)), uidDiagnostics(string(data), uids)...)
+	if !isOpen(state, filename) && (reload || len(diagnostics) > 0) {
+		params := PublishDiagnosticParams{
+			URI:         pathToURI(filename),
+			Diagnostics: diagnostics,
+		}
+
+		if err := conn.Notify("textDocument/publishDiagnostics", params); err != nil {
+			return nil, err
+		}
 	}
 
+	// the keys that could be parsed are still used, so one typo doesn't lose the whole project
+	projectConfig, syntaxErrors := analysis.ParseGodotProjectFile(data)
+	if len(syntaxErrors) > 0 {
+		logger.Warn("loaded Godot project with syntax errors", "file", filename, "error", syntaxErrors[0])
+	}
+
+	state.Lock()
 	state.ProjectConfig = *projectConfig
+	state.Unlock()
+
+	if reload {
+		logger.Info("reloaded Godot project", "name", projectConfig.ApplicationName)
+	} else {
+		logger.Info("loaded Godot project", "name", projectConfig.ApplicationName)
+	}
 
-	logger.Printf("loaded Godot project: %s\n", state.ProjectConfig.ApplicationName)
+	return projectConfig, nil
+}
+
+// checks if the client has a file open
+func isOpen(state *ServerState, filename string) bool {
+	state.RLock()
+	defer state.RUnlock()
+
+	for uri := range state.Files {
+		if sameFile(uri, filename) {
+			return true
+		}
+	}
 
-	return nil
+	return false
 }
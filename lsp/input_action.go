@@ -0,0 +1,163 @@
+package lsp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gdx/analysis"
+	"gdx/analysis/lexer"
+)
+
+// methods of Input and InputEvent that take input action names as string arguments
+var inputActionMethods = map[string]bool{
+	"is_action":               true,
+	"is_action_pressed":       true,
+	"is_action_released":      true,
+	"is_action_just_pressed":  true,
+	"is_action_just_released": true,
+	"get_action_strength":     true,
+	"get_action_raw_strength": true,
+	"get_axis":                true,
+	"get_vector":              true,
+	"action_press":            true,
+	"action_release":          true,
+}
+
+// a string naming an input action, such as "jump" in Input.is_action_pressed("jump")
+type actionArgument struct {
+	// the contents of the string, unterminated strings run to the end of the line
+	Name  string
+	Start lexer.Position
+	End   lexer.Position
+}
+
+// finds the input action argument that contains the offset. The offset can be
+// right after the last character so an unterminated string can be completed
+func actionArgumentAt(source string, offset int) (actionArgument, bool) {
+	tokens, _ := lexer.NewScanner(source).ScanTokens()
+
+	for i, token := range tokens {
+		if offset <= token.Start.Offset || offset > token.End.Offset {
+			continue
+		}
+
+		name, ok := stringContents(token)
+		if !ok || !isActionCall(tokens[:i]) {
+			return actionArgument{}, false
+		}
+
+		return actionArgument{Name: name, Start: token.Start, End: token.End}, true
+	}
+
+	return actionArgument{}, false
+}
+
+// warns about input actions passed to Input methods that project.godot doesn't declare.
+// Actions starting with ui_ are built into Godot so they don't have to be declared
+func actionDiagnostics(source string, tokens []lexer.Token, project *analysis.GodotProjectFile) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+
+	// without a project there is nothing to check against
+	if project.Config == nil {
+		return diagnostics
+	}
+
+	for i, token := range tokens {
+		if token.Type != lexer.TokenString && token.Type != lexer.TokenStringName {
+			continue
+		}
+
+		name, _ := stringContents(token)
+		if strings.HasPrefix(name, "ui_") || !isActionCall(tokens[:i]) {
+			continue
+		}
+
+		if _, ok := project.InputConfig(name); ok {
+			continue
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    toRange(source, token.Start, token.End),
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  fmt.Sprintf("input action '%s' isn't declared in project.godot", name),
+		})
+	}
+
+	return diagnostics
+}
+
+// returns the contents of a string or StringName token, which may be unterminated
+func stringContents(token lexer.Token) (string, bool) {
+	switch token.Type {
+	case lexer.TokenString, lexer.TokenStringName:
+		value, _ := token.Literal.(string)
+		return value, true
+	case lexer.TokenUnknown:
+		text := strings.TrimPrefix(token.Lexeme, "&")
+		if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+			return text[1:], true
+		}
+	}
+
+	return "", false
+}
+
+// checks the tokens before a string end with a call to an input action method,
+// skipping the arguments before the string
+func isActionCall(tokens []lexer.Token) bool {
+	i := len(tokens) - 1
+	for i >= 0 {
+		if _, ok := stringContents(tokens[i]); !ok && tokens[i].Type != lexer.TokenComma {
+			break
+		}
+		i--
+	}
+
+	if i < 2 || tokens[i].Type != lexer.TokenLParen {
+		return false
+	}
+
+	method := tokens[i-1]
+	return method.Type == lexer.TokenIdentifier && inputActionMethods[method.Lexeme] && tokens[i-2].Type == lexer.TokenPeriod
+}
+
+// completes the input actions declared in project.godot
+func generateActionCompletionItems(inputs []analysis.InputConfig) []CompletionItem {
+	result := make([]CompletionItem, 0)
+
+	for _, input := range inputs {
+		result = append(result, CompletionItem{
+			Label:         input.Name,
+			Kind:          Constant,
+			Detail:        input.Keybinding,
+			Documentation: fmt.Sprintf("input action with a deadzone of %s", formatDeadzone(input.Deadzone)),
+		})
+	}
+
+	return result
+}
+
+// describes an input action in markdown for hovers
+func describeAction(input analysis.InputConfig) string {
+	var result strings.Builder
+
+	fmt.Fprintf(&result, "**%s** input action\n\nDeadzone: %s\n", input.Name, formatDeadzone(input.Deadzone))
+
+	if len(input.Events) == 0 {
+		result.WriteString("\nNo events are bound to this action\n")
+	} else {
+		result.WriteString("\n")
+	}
+
+	for _, event := range input.Events {
+		fmt.Fprintf(&result, "- %s\n", event)
+	}
+
+	return result.String()
+}
+
+func formatDeadzone(deadzone float32) string {
+	return strconv.FormatFloat(float64(deadzone), 'g', -1, 32)
+}
@@ -0,0 +1,30 @@
+package lsp
+
+import "testing"
+
+func TestActionArgumentAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		offset   int
+		expected string
+		found    bool
+	}{
+		{name: "First argument", source: `Input.is_action_pressed("jump")`, offset: 26, expected: "jump", found: true},
+		{name: "Later argument", source: `Input.get_vector("left", "right", "up", "down")`, offset: 36, expected: "up", found: true},
+		{name: "Event method", source: `event.is_action_released(&"jump")`, offset: 30, expected: "jump", found: true},
+		{name: "Unterminated", source: "Input.is_action_just_pressed(\"ju\n", offset: 32, expected: "ju", found: true},
+		{name: "Other method", source: `print("jump")`, offset: 8, found: false},
+		{name: "Outside the string", source: `Input.is_action_pressed("jump")`, offset: 10, found: false},
+		{name: "Not a string", source: `Input.is_action_pressed(action)`, offset: 27, found: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			argument, found := actionArgumentAt(test.source, test.offset)
+			if found != test.found || argument.Name != test.expected {
+				t.Errorf("expected %q (%v), got %q (%v)", test.expected, test.found, argument.Name, found)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package lsp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gdx/rpc"
+	"log/slog"
+	"strings"
+)
+
+type MessageType = int
+
+const (
+	MessageTypeError   MessageType = 1
+	MessageTypeWarning MessageType = 2
+	MessageTypeInfo    MessageType = 3
+	MessageTypeLog     MessageType = 4
+)
+
+type LogMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+
+// a slog.Handler that forwards log records to the client as window/logMessage notifications
+type ClientLogHandler struct {
+	conn  *rpc.Conn
+	level slog.Leveler
+	// attributes added with WithAttrs, already formatted
+	attrs  string
+	prefix string
+}
+
+func NewClientLogHandler(conn *rpc.Conn, level slog.Leveler) *ClientLogHandler {
+	return &ClientLogHandler{conn: conn, level: level}
+}
+
+func (h *ClientLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ClientLogHandler) Handle(_ context.Context, record slog.Record) error {
+	var message strings.Builder
+	message.WriteString(record.Message)
+	message.WriteString(h.attrs)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		writeAttr(&message, h.prefix, attr)
+		return true
+	})
+
+	return h.conn.Notify("window/logMessage", LogMessageParams{
+		Type:    messageType(record.Level),
+		Message: message.String(),
+	})
+}
+
+func (h *ClientLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var formatted strings.Builder
+	formatted.WriteString(h.attrs)
+	for _, attr := range attrs {
+		writeAttr(&formatted, h.prefix, attr)
+	}
+
+	handler := *h
+	handler.attrs = formatted.String()
+	return &handler
+}
+
+func (h *ClientLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	handler := *h
+	handler.prefix = h.prefix + name + "."
+	return &handler
+}
+
+func writeAttr(builder *strings.Builder, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, child := range attr.Value.Group() {
+			writeAttr(builder, prefix, child)
+		}
+		return
+	}
+
+	fmt.Fprintf(builder, " %s%s=%v", prefix, attr.Key, attr.Value)
+}
+
+func messageType(level slog.Level) MessageType {
+	switch {
+	case level >= slog.LevelError:
+		return MessageTypeError
+	case level >= slog.LevelWarn:
+		return MessageTypeWarning
+	case level >= slog.LevelInfo:
+		return MessageTypeInfo
+	default:
+		return MessageTypeLog
+	}
+}
+
+// a slog.Handler that passes records to several handlers, such as a log file and the client
+type MultiHandler []slog.Handler
+
+func (m MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range m {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m MultiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range m {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(MultiHandler, len(m))
+	for i, handler := range m {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+
+	return handlers
+}
+
+func (m MultiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(MultiHandler, len(m))
+	for i, handler := range m {
+		handlers[i] = handler.WithGroup(name)
+	}
+
+	return handlers
+}
@@ -1,25 +1,35 @@
 package lsp
 
-import "gdx/analysis"
+import (
+	"encoding/json"
+	"sync"
+
+	"gdx/analysis"
+)
 
 const ServerName string = "gdx"
 
+// state shared between handlers. Handlers can run concurrently so the fields
+// must only be accessed while holding the lock
 type ServerState struct {
-	Shutdown      bool
-	WorkspacePath string
-	Files         map[string]string
+	sync.RWMutex
+	Initialized        bool
+	Shutdown           bool
+	Trace              TraceValue
+	WorkspacePath      string
+	ClientCapabilities ClientCapabilities
+	Files              map[string]*Document
+	// replaced as a whole when project.godot changes, so a copy taken while holding the lock stays consistent
 	ProjectConfig analysis.GodotProjectFile
+	// nil until the workspace has been indexed, replaced when files with UIDs change
+	UIDs *analysis.UIDIndex
+	// nil until the workspace has been indexed, replaced when scripts change
+	Classes *analysis.ClassIndex
 }
 
 type RequestMessage struct {
-	ID     int    `json:"id"`
-	Method string `json:"method"`
-}
-
-type ResponseMessage struct {
-	RPC   string `json:"jsonrpc"`
-	ID    int    `json:"id"`
-	Error any    `json:"error,omitempty"`
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
 }
 
 type Notification struct {
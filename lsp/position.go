@@ -0,0 +1,45 @@
+package lsp
+
+import (
+	"unicode/utf8"
+
+	"gdx/analysis/lexer"
+)
+
+// converts a position in the source into an LSP position. LSP counts
+// characters in UTF-16 code units while the lexer counts bytes
+func toPosition(source string, position lexer.Position) Position {
+	lineStart := position.Offset - position.Column
+	if lineStart < 0 || position.Offset > len(source) {
+		return Position{Line: uint(max(position.Line-1, 0))}
+	}
+
+	return Position{
+		Line:      uint(position.Line - 1),
+		Character: uint(utf16Length(source[lineStart:position.Offset])),
+	}
+}
+
+func toRange(source string, start lexer.Position, end lexer.Position) Range {
+	return Range{
+		Start: toPosition(source, start),
+		End:   toPosition(source, end),
+	}
+}
+
+// counts the number of UTF-16 code units needed to encode a string
+func utf16Length(text string) int {
+	length := 0
+	for len(text) > 0 {
+		char, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+
+		if char >= 0x10000 {
+			length += 2
+		} else {
+			length += 1
+		}
+	}
+
+	return length
+}
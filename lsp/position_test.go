@@ -0,0 +1,42 @@
+package lsp
+
+import (
+	"testing"
+
+	"gdx/analysis/lexer"
+)
+
+func TestToPosition(t *testing.T) {
+	source := "var a = 1\nvar größe = \"😀\" + b"
+
+	tests := []struct {
+		name     string
+		position lexer.Position
+		expected Position
+	}{
+		{
+			name:     "First line",
+			position: lexer.Position{Line: 1, Column: 4, Offset: 4},
+			expected: Position{Line: 0, Character: 4},
+		},
+		{
+			name:     "After multi-byte identifier",
+			position: lexer.Position{Line: 2, Column: 12, Offset: 22},
+			expected: Position{Line: 1, Character: 10},
+		},
+		{
+			name:     "After surrogate pair",
+			position: lexer.Position{Line: 2, Column: 20, Offset: 30},
+			expected: Position{Line: 1, Character: 16},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := toPosition(source, test.position)
+			if actual != test.expected {
+				t.Errorf("expected %+v, got %+v", test.expected, actual)
+			}
+		})
+	}
+}
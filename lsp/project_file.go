@@ -0,0 +1,157 @@
+package lsp
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gdx/analysis"
+)
+
+// checks if a document is a project.godot file, which is handled differently to scripts
+func isProjectFile(uri string) bool {
+	return path.Base(uri) == "project.godot"
+}
+
+// reports syntax errors and settings Godot doesn't have
+func projectFileDiagnostics(source string) []Diagnostic {
+	config, syntaxErrors := analysis.ParseConfigFile([]byte(source))
+
+	diagnostics := make([]Diagnostic, 0)
+	for _, serr := range syntaxErrors {
+		diagnostics = append(diagnostics, errorDiagnostic(source, serr.Range.Start, serr.Range.End, serr.Message))
+	}
+
+	for _, section := range config.Sections {
+		for _, key := range section.Keys {
+			setting := settingPath(section.Name, key.Name)
+			if !analysis.UnknownProjectSetting(setting) {
+				continue
+			}
+
+			diagnostics = append(diagnostics, Diagnostic{
+				Range:    toRange(source, key.NameRange.Start, key.NameRange.End),
+				Severity: SeverityWarning,
+				Source:   "gdx",
+				Message:  fmt.Sprintf("unknown project setting '%s'", setting),
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// the path ProjectSettings uses for a key, keys before the first section have no section in their path
+func settingPath(section string, key string) string {
+	if section == "" {
+		return key
+	}
+
+	return section + "/" + key
+}
+
+// completes section names after a '[' and setting keys at the start of a line
+func projectFileCompletionItems(source string, position Position) []CompletionItem {
+	offset := offsetAt(source, position)
+	lineStart := strings.LastIndexByte(source[:offset], '\n') + 1
+	line := source[lineStart:offset]
+	typed := strings.TrimLeft(line, " \t")
+
+	result := make([]CompletionItem, 0)
+
+	switch {
+	case strings.HasPrefix(typed, "[") && !strings.Contains(typed, "]"):
+		for _, section := range analysis.ProjectSections {
+			result = append(result, CompletionItem{
+				Label:  section,
+				Kind:   Module,
+				Detail: "project.godot section",
+			})
+		}
+	case typed == "" || !strings.ContainsAny(typed, `=;"[]{},`):
+		section := sectionAt(source, lineStart)
+
+		// the typed text is replaced since keys contain slashes, which editors don't treat as part of a word
+		start := Position{Line: position.Line, Character: uint(utf16Length(line) - utf16Length(typed))}
+		replaced := Range{Start: start, End: position}
+
+		for _, setting := range analysis.ProjectSettings {
+			key, ok := strings.CutPrefix(setting.Path, section+"/")
+			if section == "" {
+				key, ok = setting.Path, !strings.Contains(setting.Path, "/")
+			}
+			if !ok {
+				continue
+			}
+
+			result = append(result, CompletionItem{
+				Label:         key,
+				Kind:          Property,
+				Detail:        setting.Type,
+				Documentation: fmt.Sprintf("%s Defaults to %s.", setting.Description, setting.Default),
+				TextEdit:      &TextEdit{Range: replaced, NewText: key},
+			})
+		}
+	}
+
+	return result
+}
+
+// finds the name of the section the line starting at offset is in, from the closest tag above it
+func sectionAt(source string, offset int) string {
+	for offset > 0 {
+		lineStart := strings.LastIndexByte(source[:offset-1], '\n') + 1
+		line := source[lineStart : offset-1]
+		offset = lineStart
+
+		if name, ok := strings.CutPrefix(line, "["); ok {
+			end := strings.IndexAny(name, "] \t")
+			if end == -1 {
+				end = len(name)
+			}
+			return name[:end]
+		}
+	}
+
+	return ""
+}
+
+// describes the setting or input action whose key is under the position
+func projectFileHover(source string, position Position) *Hover {
+	offset := offsetAt(source, position)
+	config, _ := analysis.ParseConfigFile([]byte(source))
+
+	for _, section := range config.Sections {
+		for _, key := range section.Keys {
+			if offset < key.NameRange.Start.Offset || offset >= key.NameRange.End.Offset {
+				continue
+			}
+
+			keyRange := toRange(source, key.NameRange.Start, key.NameRange.End)
+
+			if section.Name == "input" {
+				project, _ := analysis.ParseGodotProjectFile([]byte(source))
+				input, ok := project.InputConfig(key.Name)
+				if !ok {
+					return nil
+				}
+
+				return &Hover{Contents: MarkupContent{Kind: "markdown", Value: describeAction(input)}, Range: &keyRange}
+			}
+
+			setting, ok := analysis.LookupProjectSetting(settingPath(section.Name, key.Name))
+			if !ok {
+				return nil
+			}
+
+			return &Hover{Contents: MarkupContent{Kind: "markdown", Value: describeSetting(setting)}, Range: &keyRange}
+		}
+	}
+
+	return nil
+}
+
+// describes a project setting in markdown for hovers
+func describeSetting(setting analysis.ProjectSetting) string {
+	return fmt.Sprintf("**%s**: `%s`\n\n%s\n\nDefault: `%s`\n", setting.Path, setting.Type, setting.Description, setting.Default)
+}
@@ -0,0 +1,47 @@
+package lsp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectFileKeyCompletion(t *testing.T) {
+	source := "[application]\n\nconfig/name=\"Game\"\n\n[display]\n\n  window/stretch/a\n"
+
+	items := projectFileCompletionItems(source, Position{Line: 6, Character: 18})
+
+	var found *CompletionItem
+	for i, item := range items {
+		if item.Label == "config/name" {
+			t.Errorf("expected only keys in the display section to be completed")
+		}
+		if item.Label == "window/stretch/aspect" {
+			found = &items[i]
+		}
+	}
+
+	if found == nil {
+		t.Fatalf("expected window/stretch/aspect to be completed, got %+v", items)
+	}
+
+	expected := &TextEdit{
+		Range:   Range{Start: Position{Line: 6, Character: 2}, End: Position{Line: 6, Character: 18}},
+		NewText: "window/stretch/aspect",
+	}
+	if !reflect.DeepEqual(found.TextEdit, expected) {
+		t.Errorf("expected %+v, got %+v", expected, found.TextEdit)
+	}
+
+	if items := projectFileCompletionItems(source, Position{Line: 2, Character: 14}); len(items) != 0 {
+		t.Errorf("expected no completions in a value, got %d", len(items))
+	}
+}
+
+func TestProjectFileHoverWithSyntaxErrors(t *testing.T) {
+	source := "[application]\n\nconfig/name=\"Game\" \"\n\n[input]\n\njump={\n\"deadzone\": 0.5,\n\"events\": []\n}\n"
+
+	hover := projectFileHover(source, Position{Line: 6, Character: 1})
+	if hover == nil || hover.Contents.Value != "**jump** input action\n\nDeadzone: 0.5\n\nNo events are bound to this action\n" {
+		t.Errorf("expected the jump action to be described despite the error, got %+v", hover)
+	}
+}
@@ -0,0 +1,132 @@
+package lsp
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"gdx/analysis"
+)
+
+// properties every resource has, which are saved in .tres files without being declared by the script
+var resourceProperties = map[string]bool{
+	"resource_local_to_scene":  true,
+	"resource_name":            true,
+	"resource_path":            true,
+	"resource_scene_unique_id": true,
+	"script":                   true,
+}
+
+// checks if a document is a resource saved in the .tres text format
+func isResourceFile(uri string) bool {
+	return path.Ext(uri) == ".tres"
+}
+
+// reports syntax errors and properties the resource's script doesn't declare
+func resourceFileDiagnostics(source string, state *ServerState) []Diagnostic {
+	resource, syntaxErrors := analysis.ParseResourceFile([]byte(source))
+
+	diagnostics := make([]Diagnostic, 0)
+	for _, serr := range syntaxErrors {
+		diagnostics = append(diagnostics, errorDiagnostic(source, serr.Range.Start, serr.Range.End, serr.Message))
+	}
+
+	class, ok := resourceScript(resource, state)
+	// only scripts that extend Resource are checked, other built-in classes have properties gdx doesn't know about
+	if !ok || class.Base != "Resource" {
+		return diagnostics
+	}
+
+	for _, property := range resource.Properties {
+		if _, ok := class.Property(property.Name); ok || isResourceProperty(property.Name) {
+			continue
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    toRange(source, property.NameRange.Start, property.NameRange.End),
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  fmt.Sprintf("property '%s' isn't declared in %s", property.Name, class.Path),
+		})
+	}
+
+	return diagnostics
+}
+
+func isResourceProperty(name string) bool {
+	return resourceProperties[name] || path.Dir(name) == "metadata"
+}
+
+// finds where the property under the cursor is declared in the resource's script
+func resourcePropertyDefinition(source string, position Position, state *ServerState) (*Location, bool) {
+	resource, _ := analysis.ParseResourceFile([]byte(source))
+	offset := offsetAt(source, position)
+
+	var name string
+	for _, property := range resource.Properties {
+		if property.NameRange.Start.Offset <= offset && offset <= property.NameRange.End.Offset {
+			name = property.Name
+		}
+	}
+
+	if name == "" {
+		return nil, false
+	}
+
+	class, ok := resourceScript(resource, state)
+	if !ok {
+		return nil, false
+	}
+
+	property, ok := class.Property(name)
+	if !ok {
+		return nil, false
+	}
+
+	state.RLock()
+	filename, _ := analysis.ResourceFilename(state.WorkspacePath, property.Path)
+	state.RUnlock()
+
+	script, err := readWorkspaceFile(state, filename)
+	if err != nil {
+		return nil, false
+	}
+
+	return &Location{
+		URI:   pathToURI(filename),
+		Range: toRange(string(script), property.Range.Start, property.Range.End),
+	}, true
+}
+
+// loads the script attached to a resource
+func resourceScript(resource *analysis.ResourceFile, state *ServerState) (*analysis.ScriptClass, bool) {
+	if resource.Script == nil {
+		return nil, false
+	}
+
+	state.RLock()
+	workspacePath := state.WorkspacePath
+	classes := state.Classes
+	state.RUnlock()
+
+	class, err := analysis.LoadScriptClass(workspacePath, resource.Script.Path, classes, func(filename string) ([]byte, error) {
+		return readWorkspaceFile(state, filename)
+	})
+
+	return class, err == nil
+}
+
+// reads a file from the open document if the client has it open, since it may not be saved yet
+func readWorkspaceFile(state *ServerState, filename string) ([]byte, error) {
+	state.RLock()
+	for uri, document := range state.Files {
+		if sameFile(uri, filename) {
+			text := document.Text
+			state.RUnlock()
+			return []byte(text), nil
+		}
+	}
+	state.RUnlock()
+
+	return os.ReadFile(filename)
+}
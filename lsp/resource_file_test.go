@@ -0,0 +1,148 @@
+package lsp
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResourceFile(t *testing.T) {
+	workspace := filepath.Join("..", "testdata", "project")
+
+	source, err := os.ReadFile(filepath.Join(workspace, "sword.tres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &ServerState{WorkspacePath: workspace, Files: make(map[string]*Document)}
+
+	expectedDiagnostics := []Diagnostic{
+		{
+			Range:    Range{Start: Position{Line: 9, Character: 0}, End: Position{Line: 9, Character: 6}},
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  "property 'weight' isn't declared in res://weapon.gd",
+		},
+	}
+
+	diagnostics := resourceFileDiagnostics(string(source), state)
+	if !reflect.DeepEqual(diagnostics, expectedDiagnostics) {
+		t.Errorf("expected %+v, got %+v", expectedDiagnostics, diagnostics)
+	}
+
+	tests := []struct {
+		name     string
+		position Position
+		expected *Location
+	}{
+		{
+			name:     "inherited property",
+			position: Position{Line: 6, Character: 2},
+			expected: &Location{
+				URI:   pathToURI(filepath.Join(workspace, "item.gd")),
+				Range: Range{Start: Position{Line: 3, Character: 12}, End: Position{Line: 3, Character: 17}},
+			},
+		},
+		{
+			name:     "end of the property name",
+			position: Position{Line: 8, Character: 6},
+			expected: &Location{
+				URI:   pathToURI(filepath.Join(workspace, "weapon.gd")),
+				Range: Range{Start: Position{Line: 3, Character: 12}, End: Position{Line: 3, Character: 18}},
+			},
+		},
+		{
+			name:     "undeclared property",
+			position: Position{Line: 9, Character: 2},
+		},
+		{
+			name:     "value",
+			position: Position{Line: 6, Character: 10},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location, _ := resourcePropertyDefinition(string(source), test.position, state)
+			if !reflect.DeepEqual(location, test.expected) {
+				t.Errorf("expected %+v, got %+v", test.expected, location)
+			}
+		})
+	}
+}
+
+func TestResourceFileClassNameParent(t *testing.T) {
+	workspace := filepath.Join("..", "testdata", "project")
+
+	source, err := os.ReadFile(filepath.Join(workspace, "shield.tres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &ServerState{WorkspacePath: workspace, Files: make(map[string]*Document)}
+	loadClasses(slog.New(slog.NewTextHandler(io.Discard, nil)), state)
+
+	// shield.gd extends Item by its class_name, so the chain still ends at Resource
+	expectedDiagnostics := []Diagnostic{
+		{
+			Range:    Range{Start: Position{Line: 8, Character: 0}, End: Position{Line: 8, Character: 10}},
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  "property 'durability' isn't declared in res://shield.gd",
+		},
+	}
+
+	diagnostics := resourceFileDiagnostics(string(source), state)
+	if !reflect.DeepEqual(diagnostics, expectedDiagnostics) {
+		t.Errorf("expected %+v, got %+v", expectedDiagnostics, diagnostics)
+	}
+
+	expected := &Location{
+		URI:   pathToURI(filepath.Join(workspace, "item.gd")),
+		Range: Range{Start: Position{Line: 3, Character: 12}, End: Position{Line: 3, Character: 17}},
+	}
+
+	location, _ := resourcePropertyDefinition(string(source), Position{Line: 6, Character: 2}, state)
+	if !reflect.DeepEqual(location, expected) {
+		t.Errorf("expected %+v, got %+v", expected, location)
+	}
+}
+
+func TestResourceFileRelativeExtends(t *testing.T) {
+	workspace := filepath.Join("..", "testdata", "project")
+
+	source, err := os.ReadFile(filepath.Join(workspace, "armory", "dagger.tres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &ServerState{WorkspacePath: workspace, Files: make(map[string]*Document)}
+
+	// armory/dagger.gd extends "../weapon.gd", which is relative to the script's directory
+	expectedDiagnostics := []Diagnostic{
+		{
+			Range:    Range{Start: Position{Line: 8, Character: 0}, End: Position{Line: 8, Character: 4}},
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  "property 'edge' isn't declared in res://armory/dagger.gd",
+		},
+	}
+
+	diagnostics := resourceFileDiagnostics(string(source), state)
+	if !reflect.DeepEqual(diagnostics, expectedDiagnostics) {
+		t.Errorf("expected %+v, got %+v", expectedDiagnostics, diagnostics)
+	}
+
+	expected := &Location{
+		URI:   pathToURI(filepath.Join(workspace, "weapon.gd")),
+		Range: Range{Start: Position{Line: 3, Character: 12}, End: Position{Line: 3, Character: 18}},
+	}
+
+	location, _ := resourcePropertyDefinition(string(source), Position{Line: 6, Character: 2}, state)
+	if !reflect.DeepEqual(location, expected) {
+		t.Errorf("expected %+v, got %+v", expected, location)
+	}
+}
@@ -1,16 +1,26 @@
 package lsp
 
 import (
-	"log"
-	"os"
+	"encoding/json"
+	"gdx/rpc"
+	"log/slog"
 )
 
-func HandleShutdown(state *ServerState, logger *log.Logger) {
-	logger.Println("shutting down GDX")
+func HandleShutdown(conn *rpc.Conn, content []byte, logger *slog.Logger, state *ServerState) error {
+	var request RequestMessage
+	if err := json.Unmarshal(content, &request); err != nil {
+		return err
+	}
+
+	logger.Info("shutting down GDX")
+	state.Lock()
 	state.Shutdown = true
+	state.Unlock()
+
+	return conn.Reply(request.ID, nil)
 }
 
-func HandleExit(logger *log.Logger) {
-	logger.Println("exiting server")
-	os.Exit(0)
+// the session ends once the exit notification has been handled
+func HandleExit(logger *slog.Logger) {
+	logger.Info("exiting server")
 }
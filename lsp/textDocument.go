@@ -2,7 +2,9 @@ package lsp
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"gdx/rpc"
+	"log/slog"
 )
 
 type TextDocumentItem struct {
@@ -35,31 +37,49 @@ type DidCloseTextDocumentNotification struct {
 	Params DidCloseTextDocumentParams `json:"params"`
 }
 
+// a change to a document, if the range is missing the text is the whole new document
 type TextDocumentContentChangeEvent struct {
-	Text string `json:"text"`
+	Range *Range `json:"range,omitempty"`
+	Text  string `json:"text"`
 }
 
 type TextDocumentIdentifier struct {
 	URI string `json:"uri"`
 }
 
+// the parameters of requests about a position in a document
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
 type DidChangeTextDocumentNotificationParams struct {
-	TextDocument   TextDocumentIdentifier           `json:"textDocument"`
+	TextDocument   VersionedTextDocumentIdentifier  `json:"textDocument"`
 	ContentChanges []TextDocumentContentChangeEvent `json:"contentChanges"`
 }
 
-func HandleTextDocumentOpen(contents []byte, logger *log.Logger, state *ServerState) error {
+func HandleTextDocumentOpen(conn *rpc.Conn, contents []byte, logger *slog.Logger, state *ServerState) error {
 	var msg DidOpenTextDocumentNotification
 
 	if err := json.Unmarshal(contents, &msg); err != nil {
 		return err
 	}
 
-	logger.Printf("recieved textDocument/open for %s\n", msg.Params.TextDocument.URI)
+	logger.Debug("document opened", "uri", msg.Params.TextDocument.URI)
 
-	state.Files[msg.Params.TextDocument.URI] = msg.Params.TextDocument.Text
+	state.Lock()
+	state.Files[msg.Params.TextDocument.URI] = &Document{
+		Version: msg.Params.TextDocument.Version,
+		Text:    msg.Params.TextDocument.Text,
+	}
+	state.Unlock()
 
-	err := RunDiagnostics(state, logger, msg.Params.TextDocument.URI)
+	err := RunDiagnostics(conn, state, logger, msg.Params.TextDocument.URI)
 	if err != nil {
 		return err
 	}
@@ -67,35 +87,64 @@ func HandleTextDocumentOpen(contents []byte, logger *log.Logger, state *ServerSt
 	return nil
 }
 
-func HandleTextDocumentClose(contents []byte, logger *log.Logger, state *ServerState) error {
+func HandleTextDocumentClose(conn *rpc.Conn, contents []byte, logger *slog.Logger, state *ServerState) error {
 	var msg DidCloseTextDocumentNotification
 
 	if err := json.Unmarshal(contents, &msg); err != nil {
 		return err
 	}
 
-	logger.Printf("document %s closed", msg.Params.TextDocument.URI)
+	logger.Debug("document closed", "uri", msg.Params.TextDocument.URI)
 
+	state.Lock()
 	delete(state.Files, msg.Params.TextDocument.URI)
+	state.Unlock()
 
 	return nil
 }
 
-func HandleTextDocumentChange(contents []byte, logger *log.Logger, state *ServerState) error {
+func HandleTextDocumentChange(conn *rpc.Conn, contents []byte, logger *slog.Logger, state *ServerState) error {
 	var msg DidChangeTextDocumentNotification
 
 	if err := json.Unmarshal(contents, &msg); err != nil {
 		return err
 	}
 
-	logger.Printf("document %s changed", msg.Params.TextDocument.URI)
+	logger.Debug("document changed", "uri", msg.Params.TextDocument.URI, "version", msg.Params.TextDocument.Version)
 
-	state.Files[msg.Params.TextDocument.URI] = msg.Params.ContentChanges[0].Text
+	if err := applyDocumentChanges(state, msg.Params); err != nil {
+		return err
+	}
 
-	err := RunDiagnostics(state, logger, msg.Params.TextDocument.URI)
+	err := RunDiagnostics(conn, state, logger, msg.Params.TextDocument.URI)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// applies the changes from a didChange notification to the open document
+func applyDocumentChanges(state *ServerState, params DidChangeTextDocumentNotificationParams) error {
+	state.Lock()
+	defer state.Unlock()
+
+	document, ok := state.Files[params.TextDocument.URI]
+	if !ok {
+		return fmt.Errorf("document %s changed before it was opened", params.TextDocument.URI)
+	}
+
+	// changes are applied in order, each one to the result of the previous. They're applied
+	// to a copy so a change that fails doesn't leave the document half edited
+	updated := *document
+	for _, change := range params.ContentChanges {
+		if err := updated.ApplyChange(change); err != nil {
+			return err
+		}
+	}
+
+	updated.Version = params.TextDocument.Version
+	*document = updated
+
+	return nil
+}
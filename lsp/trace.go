@@ -0,0 +1,106 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"gdx/rpc"
+	"log/slog"
+)
+
+// how much the server reports about the messages it sends and receives
+type TraceValue = string
+
+const (
+	TraceOff      TraceValue = "off"
+	TraceMessages TraceValue = "messages"
+	TraceVerbose  TraceValue = "verbose"
+)
+
+type SetTraceNotification struct {
+	Notification
+	Params SetTraceParams `json:"params"`
+}
+
+type SetTraceParams struct {
+	Value TraceValue `json:"value"`
+}
+
+type LogTraceNotification struct {
+	RPC    string         `json:"jsonrpc"`
+	Method string         `json:"method"`
+	Params LogTraceParams `json:"params"`
+}
+
+type LogTraceParams struct {
+	Message string `json:"message"`
+	Verbose string `json:"verbose,omitempty"`
+}
+
+func HandleSetTrace(content []byte, logger *slog.Logger, state *ServerState) error {
+	var notification SetTraceNotification
+	if err := json.Unmarshal(content, &notification); err != nil {
+		return err
+	}
+
+	switch notification.Params.Value {
+	case TraceOff, TraceMessages, TraceVerbose:
+	default:
+		return fmt.Errorf("invalid trace value %q", notification.Params.Value)
+	}
+
+	logger.Debug("trace changed", "value", notification.Params.Value)
+
+	state.Lock()
+	state.Trace = notification.Params.Value
+	state.Unlock()
+
+	return nil
+}
+
+// sends a $/logTrace notification describing a message if tracing is on.
+// sent is true for messages sent by the server and false for ones it received
+func TraceMessage(conn *rpc.Conn, state *ServerState, sent bool, content []byte) error {
+	state.RLock()
+	trace := state.Trace
+	state.RUnlock()
+
+	if trace != TraceMessages && trace != TraceVerbose {
+		return nil
+	}
+
+	message, err := rpc.ParseMessage(content)
+	if err != nil {
+		return err
+	}
+
+	// tracing the trace notifications would never end
+	if message.Method == "$/logTrace" {
+		return nil
+	}
+
+	direction := "Received"
+	if sent {
+		direction = "Sending"
+	}
+
+	var description string
+	switch {
+	case message.IsRequest():
+		description = fmt.Sprintf("%s request '%s - (%s)'.", direction, message.Method, message.ID)
+	case message.IsNotification():
+		description = fmt.Sprintf("%s notification '%s'.", direction, message.Method)
+	default:
+		description = fmt.Sprintf("%s response '(%s)'.", direction, message.ID)
+	}
+
+	params := LogTraceParams{Message: description}
+	if trace == TraceVerbose {
+		params.Verbose = string(content)
+	}
+
+	return conn.Send(LogTraceNotification{
+		RPC:    "2.0",
+		Method: "$/logTrace",
+		Params: params,
+	})
+}
@@ -0,0 +1,164 @@
+package lsp
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"gdx/analysis"
+	"gdx/analysis/lexer"
+)
+
+// a uid:// identifier written in a document
+type uidReference struct {
+	UID   string
+	Start int
+	End   int
+}
+
+// finds every uid:// identifier in a document. They're found in the text rather than by parsing
+// so they're found the same way in scripts, scenes, resources and project.godot
+func uidReferences(source string) []uidReference {
+	references := make([]uidReference, 0)
+
+	offset := 0
+	for {
+		index := strings.Index(source[offset:], "uid://")
+		if index == -1 {
+			return references
+		}
+
+		start := offset + index
+		end := start + len("uid://")
+		for end < len(source) && (source[end] >= 'a' && source[end] <= 'z' || source[end] >= '0' && source[end] <= '9') {
+			end++
+		}
+
+		// Godot writes uid://<invalid> for files without a UID
+		if end > start+len("uid://") {
+			references = append(references, uidReference{UID: source[start:end], Start: start, End: end})
+		}
+
+		offset = end
+	}
+}
+
+// finds the uid:// identifier containing the offset
+func uidAt(source string, offset int) (uidReference, bool) {
+	for _, reference := range uidReferences(source) {
+		if reference.Start <= offset && offset < reference.End {
+			return reference, true
+		}
+	}
+
+	return uidReference{}, false
+}
+
+// warns about UIDs that don't belong to any file in the project, such as ones
+// left behind when a file was deleted outside the Godot editor
+func uidDiagnostics(source string, index *analysis.UIDIndex) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+
+	// without an index every UID would be reported
+	if index == nil {
+		return diagnostics
+	}
+
+	for _, reference := range uidReferences(source) {
+		if _, ok := index.Path(reference.UID); ok {
+			continue
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Range:    uidRange(source, reference),
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  fmt.Sprintf("%s doesn't belong to any file in the project", reference.UID),
+		})
+	}
+
+	return diagnostics
+}
+
+// shows the path of the file a UID belongs to
+func uidHover(source string, reference uidReference, index *analysis.UIDIndex) *Hover {
+	path, ok := index.Path(reference.UID)
+	if !ok {
+		return nil
+	}
+
+	hoverRange := uidRange(source, reference)
+
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: fmt.Sprintf("`%s`\n\n%s\n", reference.UID, path)},
+		Range:    &hoverRange,
+	}
+}
+
+// goes to the start of the file a UID belongs to
+func uidDefinition(reference uidReference, index *analysis.UIDIndex, workspacePath string) (*Location, bool) {
+	path, ok := index.Path(reference.UID)
+	if !ok {
+		return nil, false
+	}
+
+	filename, ok := analysis.ResourceFilename(workspacePath, path)
+	if !ok {
+		return nil, false
+	}
+
+	return &Location{URI: pathToURI(filename)}, true
+}
+
+func uidRange(source string, reference uidReference) Range {
+	return toRange(source, offsetPosition(source, reference.Start), offsetPosition(source, reference.End))
+}
+
+// the lexer position of a byte offset, which toRange converts to an LSP position
+func offsetPosition(source string, offset int) lexer.Position {
+	lineStart := strings.LastIndexByte(source[:offset], '\n') + 1
+
+	return lexer.Position{
+		Line:   strings.Count(source[:offset], "\n") + 1,
+		Column: offset - lineStart,
+		Offset: offset,
+	}
+}
+
+// indexes the UIDs of the project's files, replacing the index from before
+func loadUIDs(logger *slog.Logger, state *ServerState) {
+	state.RLock()
+	workspacePath := state.WorkspacePath
+	state.RUnlock()
+
+	index, err := analysis.BuildUIDIndex(workspacePath)
+	if err != nil {
+		logger.Warn("unable to index UIDs", "error", err)
+		return
+	}
+
+	state.Lock()
+	state.UIDs = index
+	state.Unlock()
+
+	logger.Debug("indexed UIDs", "count", index.Len())
+}
+
+// finds the class_name of every script in the workspace, so scripts extending one can be followed
+func loadClasses(logger *slog.Logger, state *ServerState) {
+	state.RLock()
+	workspacePath := state.WorkspacePath
+	state.RUnlock()
+
+	index, err := analysis.BuildClassIndex(workspacePath)
+	if err != nil {
+		logger.Warn("unable to index class names", "error", err)
+		return
+	}
+
+	state.Lock()
+	state.Classes = index
+	state.Unlock()
+
+	logger.Debug("indexed class names", "count", index.Len())
+}
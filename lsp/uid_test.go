@@ -0,0 +1,53 @@
+package lsp
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gdx/analysis"
+)
+
+func TestUIDReferences(t *testing.T) {
+	index := analysis.NewUIDIndex()
+	index.Add("uid://c1m5tvm3ejy7h", "res://player.gd")
+
+	source := "const Player = preload(\"uid://c1m5tvm3ejy7h\")\nconst Gone = preload(\"uid://dq0gone\")\nvar invalid = \"uid://<invalid>\"\n"
+
+	expectedDiagnostics := []Diagnostic{
+		{
+			Range:    Range{Start: Position{Line: 1, Character: 22}, End: Position{Line: 1, Character: 35}},
+			Severity: SeverityWarning,
+			Source:   "gdx",
+			Message:  "uid://dq0gone doesn't belong to any file in the project",
+		},
+	}
+
+	diagnostics := uidDiagnostics(source, index)
+	if !reflect.DeepEqual(diagnostics, expectedDiagnostics) {
+		t.Errorf("expected %+v, got %+v", expectedDiagnostics, diagnostics)
+	}
+
+	reference, ok := uidAt(source, offsetAt(source, Position{Line: 0, Character: 30}))
+	if !ok || reference.UID != "uid://c1m5tvm3ejy7h" {
+		t.Fatalf("expected to find the player's UID, got %+v", reference)
+	}
+
+	hover := uidHover(source, reference, index)
+	expectedHover := &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "`uid://c1m5tvm3ejy7h`\n\nres://player.gd\n"},
+		Range:    &Range{Start: Position{Line: 0, Character: 24}, End: Position{Line: 0, Character: 43}},
+	}
+	if !reflect.DeepEqual(hover, expectedHover) {
+		t.Errorf("expected %+v, got %+v", expectedHover, hover)
+	}
+
+	location, ok := uidDefinition(reference, index, "/game")
+	if !ok || location.URI != pathToURI(filepath.Join("/game", "player.gd")) {
+		t.Errorf("expected to go to player.gd, got %+v", location)
+	}
+
+	if _, ok := uidAt(source, offsetAt(source, Position{Line: 0, Character: 10})); ok {
+		t.Error("expected no UID outside the string")
+	}
+}
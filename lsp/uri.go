@@ -0,0 +1,46 @@
+package lsp
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+)
+
+// converts a file path into a file:// URI
+func pathToURI(path string) string {
+	if absolute, err := filepath.Abs(path); err == nil {
+		path = absolute
+	}
+
+	uri := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return uri.String()
+}
+
+// converts a file:// URI into a file path
+func uriToPath(uri string) (string, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.Scheme != "file" {
+		return "", fmt.Errorf("%s isn't a file URI", uri)
+	}
+
+	return filepath.FromSlash(parsed.Path), nil
+}
+
+// checks if a URI refers to a file path
+func sameFile(uri string, filename string) bool {
+	path, err := uriToPath(uri)
+	if err != nil {
+		return false
+	}
+
+	absolute, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+
+	return filepath.Clean(path) == absolute
+}
@@ -0,0 +1,164 @@
+package lsp
+
+import (
+	"encoding/json"
+	"gdx/analysis"
+	"gdx/rpc"
+	"log/slog"
+	"strings"
+)
+
+type FileChangeType = int
+
+const (
+	FileChangeTypeCreated FileChangeType = 1
+	FileChangeTypeChanged FileChangeType = 2
+	FileChangeTypeDeleted FileChangeType = 3
+)
+
+type FileEvent struct {
+	URI  string         `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+type DidChangeWatchedFilesNotification struct {
+	Notification
+	Params DidChangeWatchedFilesParams `json:"params"`
+}
+
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+// reloads project.godot when it changes on disk, such as when an input action is added in
+// the Godot editor, and indexes UIDs again when files with UIDs change and class names when
+// scripts change. The open documents that use what changed are then checked again
+func HandleDidChangeWatchedFiles(conn *rpc.Conn, content []byte, logger *slog.Logger, state *ServerState) error {
+	var msg DidChangeWatchedFilesNotification
+	if err := json.Unmarshal(content, &msg); err != nil {
+		return err
+	}
+
+	filename := projectFilePath(state)
+
+	projectChanged := false
+	uidsChanged := false
+	classesChanged := false
+	for _, change := range msg.Params.Changes {
+		logger.Debug("watched file changed", "uri", change.URI, "type", change.Type)
+
+		if sameFile(change.URI, filename) {
+			projectChanged = true
+		} else if analysis.IsUIDSource(change.URI) {
+			uidsChanged = true
+		} else if analysis.IsClassSource(change.URI) {
+			classesChanged = true
+		}
+	}
+
+	// documents only depend on other files through these names, so documents that don't mention them are unaffected
+	names := make([]string, 0)
+
+	if uidsChanged {
+		loadUIDs(logger, state)
+		names = append(names, "uid://")
+	}
+
+	// project.godot is loaded again after UIDs change too, to check its UIDs again
+	if projectChanged || uidsChanged {
+		state.RLock()
+		previous := state.ProjectConfig
+		state.RUnlock()
+
+		project, err := loadProject(conn, logger, state, true)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, changedProjectNames(&previous, project)...)
+	}
+
+	if classesChanged {
+		loadClasses(logger, state)
+	}
+
+	if len(names) == 0 && !classesChanged {
+		return nil
+	}
+
+	uris := make([]string, 0)
+	state.RLock()
+	for uri, document := range state.Files {
+		// resources are checked against the scripts they use, which may now extend other classes
+		if classesChanged && isResourceFile(uri) {
+			uris = append(uris, uri)
+			continue
+		}
+
+		for _, name := range names {
+			if strings.Contains(document.Text, name) {
+				uris = append(uris, uri)
+				break
+			}
+		}
+	}
+	state.RUnlock()
+
+	for _, uri := range uris {
+		if err := RunDiagnostics(conn, state, logger, uri); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lists the autoloads, input actions and layer names that were added, removed or changed
+func changedProjectNames(previous *analysis.GodotProjectFile, current *analysis.GodotProjectFile) []string {
+	names := make([]string, 0)
+
+	autoloads := make(map[string]analysis.Autoload)
+	for _, autoload := range previous.Autoloads {
+		autoloads[autoload.Name] = autoload
+	}
+	for _, autoload := range current.Autoloads {
+		if old, ok := autoloads[autoload.Name]; !ok || old != autoload {
+			names = append(names, autoload.Name)
+		}
+		delete(autoloads, autoload.Name)
+	}
+	for name := range autoloads {
+		names = append(names, name)
+	}
+
+	inputs := make(map[string]analysis.InputConfig)
+	for _, input := range previous.InputConfigs {
+		inputs[input.Name] = input
+	}
+	for _, input := range current.InputConfigs {
+		old, ok := inputs[input.Name]
+		if !ok || old.Deadzone != input.Deadzone || old.Keybinding != input.Keybinding {
+			names = append(names, input.Name)
+		}
+		delete(inputs, input.Name)
+	}
+	for name := range inputs {
+		names = append(names, name)
+	}
+
+	layers := make(map[analysis.LayerName]bool)
+	for _, layer := range previous.LayerNames {
+		layers[layer] = true
+	}
+	for _, layer := range current.LayerNames {
+		if !layers[layer] {
+			names = append(names, layer.Name)
+		}
+		delete(layers, layer)
+	}
+	for layer := range layers {
+		names = append(names, layer.Name)
+	}
+
+	return names
+}
@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gdx/rpc"
+)
+
+func TestHandleDidChangeWatchedFiles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	workspace := t.TempDir()
+	filename := filepath.Join(workspace, "project.godot")
+	writeProject := func(actions string) {
+		contents := "config_version=5\n\n[input]\n\n" + actions
+		if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeProject("jump={\n\"deadzone\": 0.5,\n\"events\": []\n}\n")
+
+	var output bytes.Buffer
+	conn := rpc.NewConn(&output)
+	state := &ServerState{
+		WorkspacePath: workspace,
+		Files: map[string]*Document{
+			"file:///player.gd": {Text: "func _process(delta):\n\tif Input.is_action_pressed(\"dash\"):\n\t\tpass\n"},
+			"file:///enemy.gd":  {Text: "func _ready():\n\tpass\n"},
+		},
+	}
+
+	if err := HandleInitialized(context.Background(), conn, logger, state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := RunDiagnostics(conn, state, logger, "file:///player.gd"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	writeProject("dash={\n\"deadzone\": 0.5,\n\"events\": []\n}\njump={\n\"deadzone\": 0.5,\n\"events\": []\n}\n")
+
+	content := `{"jsonrpc":"2.0","method":"workspace/didChangeWatchedFiles","params":{"changes":[{"uri":"` + pathToURI(filename) + `","type":2}]}}`
+	if err := HandleDidChangeWatchedFiles(conn, []byte(content), logger, state); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := state.ProjectConfig.InputConfig("dash"); !ok {
+		t.Error("expected the reloaded project to declare dash")
+	}
+
+	expected := []string{
+		`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///player.gd","diagnostics":[` +
+			`{"range":{"start":{"line":1,"character":28},"end":{"line":1,"character":34}},"severity":2,"source":"gdx","message":"input action 'dash' isn't declared in project.godot"}]}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"` + pathToURI(filename) + `","diagnostics":[]}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///player.gd","diagnostics":[]}}`,
+	}
+
+	actual := messages(t, &output)
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
@@ -1,95 +1,310 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"gdx/lsp"
 	"gdx/rpc"
+	"gdx/session"
+	"gdx/transport"
 	"gdx/version"
 )
 
-func getLogger(filename string) *log.Logger {
+// creates the handler for the server's own log. Without a filename logs go to
+// gdx/gdx.log in the user's cache directory, or stderr if that can't be
+// created. A filename of "-" always logs to stderr
+func getLogHandler(filename string, level slog.Leveler) (slog.Handler, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	if filename == "-" {
+		return slog.NewTextHandler(os.Stderr, options), nil
+	}
+
+	if filename == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return slog.NewTextHandler(os.Stderr, options), nil
+		}
+
+		if err := os.MkdirAll(filepath.Join(cacheDir, "gdx"), 0755); err != nil {
+			return slog.NewTextHandler(os.Stderr, options), nil
+		}
+
+		filename = filepath.Join(cacheDir, "gdx", "gdx.log")
+	}
+
 	logfile, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return log.New(logfile, "[gdx-server]", log.Ldate|log.Ltime|log.Lshortfile)
+	return slog.NewTextHandler(logfile, options), nil
 }
 
-func handleMessage(method string, content []byte, logger *log.Logger, state *lsp.ServerState) error {
-	if state.Shutdown {
-		error := lsp.ResponseError{
-			Code:    lsp.ErrCodeInvalidRequest,
-			Message: "server is shutdown",
-		}
+func parseLogLevel(name string) (slog.Level, error) {
+	switch name {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+
+	return 0, fmt.Errorf("invalid log level %q, expected debug, info, warn or error", name)
+}
 
-		msg, _ := rpc.EncodeMessage(error)
-		fmt.Printf("%s", msg)
+func handleMessage(ctx context.Context, conn *rpc.Conn, method string, content []byte, logger *slog.Logger, state *lsp.ServerState) error {
+	switch method {
+	case "initialize":
+		return lsp.HandleInitialize(conn, content, logger, state)
+	case "initialized":
+		return lsp.HandleInitialized(ctx, conn, logger, state)
+	case "shutdown":
+		return lsp.HandleShutdown(conn, content, logger, state)
+	case "exit":
+		lsp.HandleExit(logger)
 		return nil
-	} else {
-		switch method {
-		case "initialize":
-			return lsp.HandleInitialize(content, logger, state)
-		case "initialized":
-			return lsp.HandleInitialized(logger, state)
-		case "shutdown":
-			lsp.HandleShutdown(state, logger)
-			return nil
-		case "exit":
-			lsp.HandleExit(logger)
-			return nil
-		case "textDocument/didOpen":
-			return lsp.HandleTextDocumentOpen(content, logger, state)
-		case "textDocument/didChange":
-			return lsp.HandleTextDocumentChange(content, logger, state)
-		case "textDocument/didClose":
-			return lsp.HandleTextDocumentClose(content, logger, state)
-		case "textDocument/completion":
-			return lsp.HandleCompletion(content, logger)
+	case "$/setTrace":
+		return lsp.HandleSetTrace(content, logger, state)
+	case "workspace/didChangeWatchedFiles":
+		return lsp.HandleDidChangeWatchedFiles(conn, content, logger, state)
+	case "textDocument/didOpen":
+		return lsp.HandleTextDocumentOpen(conn, content, logger, state)
+	case "textDocument/didChange":
+		return lsp.HandleTextDocumentChange(conn, content, logger, state)
+	case "textDocument/didClose":
+		return lsp.HandleTextDocumentClose(conn, content, logger, state)
+	case "textDocument/completion":
+		return lsp.HandleCompletion(ctx, conn, content, logger, state)
+	case "textDocument/hover":
+		return lsp.HandleHover(ctx, conn, content, logger, state)
+	case "textDocument/definition":
+		return lsp.HandleDefinition(ctx, conn, content, logger, state)
+	}
+
+	return &lsp.ResponseError{
+		Code:    lsp.ErrCodeMethodNotFound,
+		Message: fmt.Sprintf("method %s is not supported", method),
+	}
+}
+
+type options struct {
+	logHandler     slog.Handler
+	logLevel       slog.Level
+	maxMessageSize int
+	// records every message of the session if set
+	recorder *session.Recorder
+}
+
+// serves a single client until it exits or disconnects. Every connection has
+// its own session state. The exit code is 0 if the client asked the server to
+// shut down before exiting and 1 otherwise
+func serve(conn io.ReadWriteCloser, options options) int {
+	defer conn.Close()
 
+	state := lsp.ServerState{
+		Trace: lsp.TraceOff,
+		Files: make(map[string]*lsp.Document),
+	}
+	client := rpc.NewConn(conn)
+
+	// only the log file is used for tracing errors since logging them to the client would trace them again
+	fileLogger := slog.New(options.logHandler)
+	logger := slog.New(lsp.MultiHandler{options.logHandler, lsp.NewClientLogHandler(client, options.logLevel)})
+
+	client.OnSend(func(content []byte) {
+		if options.recorder != nil {
+			if err := options.recorder.Record(session.DirectionOut, content); err != nil {
+				fileLogger.Warn("unable to record message", "error", err)
+			}
 		}
 
-		return nil
+		if err := lsp.TraceMessage(client, &state, true, content); err != nil {
+			fileLogger.Warn("unable to trace message", "error", err)
+		}
+	})
+
+	dispatcher := lsp.NewDispatcher(handleMessage, client, logger, &state)
+
+	reader := rpc.NewReader(conn, options.maxMessageSize)
+
+	for {
+		contents, err := reader.Read()
+		if errors.Is(err, rpc.ErrInvalidHeader) || errors.Is(err, rpc.ErrMessageTooLarge) {
+			logger.Warn("skipping message", "error", err)
+			continue
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Error("unable to read message", "error", err)
+			}
+			break
+		}
+
+		if options.recorder != nil {
+			if err := options.recorder.Record(session.DirectionIn, contents); err != nil {
+				logger.Warn("unable to record message", "error", err)
+			}
+		}
+
+		message, err := rpc.ParseMessage(contents)
+		if err != nil {
+			logger.Warn("unable to parse message", "error", err)
+			if err := client.ReplyError(rpc.NullID, &lsp.ResponseError{Code: lsp.ErrCodeParseError, Message: err.Error()}); err != nil {
+				logger.Warn("unable to reply", "error", err)
+			}
+			continue
+		}
+
+		if err := lsp.TraceMessage(client, &state, false, contents); err != nil {
+			fileLogger.Warn("unable to trace message", "error", err)
+		}
+
+		if message.IsResponse() {
+			if err := client.HandleResponse(contents); err != nil {
+				logger.Warn("unable to handle response", "error", err)
+			}
+			continue
+		}
+
+		if message.Method == "" {
+			if err := client.ReplyError(rpc.NullID, &lsp.ResponseError{Code: lsp.ErrCodeInvalidRequest, Message: "message has no method"}); err != nil {
+				logger.Warn("unable to reply", "error", err)
+			}
+			continue
+		}
+
+		dispatcher.Dispatch(message, contents)
+
+		if message.Method == "exit" {
+			break
+		}
+	}
+
+	dispatcher.Close()
+
+	state.RLock()
+	defer state.RUnlock()
+
+	if state.Shutdown {
+		return 0
+	}
+
+	return 1
+}
+
+// adds the flags shared by every way of running the server, returning a
+// function that builds the session options once the flags are parsed
+func addServerFlags(flags *flag.FlagSet) func() (options, error) {
+	maxMessageSize := flags.Int("max-message-size", rpc.DefaultMaxMessageSize, "The largest message in bytes accepted from a client")
+	logFile := flags.String("log-file", "", "Writes logs to this file, defaults to gdx/gdx.log in the user's cache directory. Use - for stderr")
+	logLevel := flags.String("log-level", "info", "The minimum level of logs to write: debug, info, warn or error")
+
+	return func() (options, error) {
+		level, err := parseLogLevel(*logLevel)
+		if err != nil {
+			return options{}, err
+		}
+
+		logHandler, err := getLogHandler(*logFile, level)
+		if err != nil {
+			return options{}, fmt.Errorf("unable to open log file: %s", err)
+		}
+
+		return options{
+			logHandler:     logHandler,
+			logLevel:       level,
+			maxMessageSize: *maxMessageSize,
+		}, nil
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "record":
+			os.Exit(record(os.Args[2:]))
+		case "replay":
+			os.Exit(replay(os.Args[2:]))
+		case "project":
+			os.Exit(project(os.Args[2:]))
+		}
+	}
+
 	v := flag.Bool("version", false, "Prints the version")
+	listen := flag.String("listen", "", "Listens for clients on an address such as tcp://127.0.0.1:6005 or unix:///tmp/gdx.sock")
+	stdio := flag.Bool("stdio", false, "Communicates with a single client over stdin and stdout (the default)")
+	serverOptions := addServerFlags(flag.CommandLine)
 
 	flag.Parse()
 
-	logger := getLogger("/home/grqphical/dev/go/gdx/log.txt")
-
 	if *v {
 		fmt.Printf("GDX version: %s\n", version.Version)
 		return
 	}
 
-	state := lsp.ServerState{
-		Files: make(map[string]string),
+	if *stdio && *listen != "" {
+		fmt.Fprintln(os.Stderr, "--stdio and --listen can't be used together")
+		os.Exit(2)
 	}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	scanner.Split(rpc.Split)
+	sessionOptions, err := serverOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	for scanner.Scan() {
-		msg := scanner.Bytes()
-		method, contents, err := rpc.DecodeMessage(msg, logger)
+	logger := slog.New(sessionOptions.logHandler)
+
+	// with a single client the process exits with the session
+	if *listen == "" {
+		conn, err := transport.Stdio().Accept()
 		if err != nil {
-			logger.Printf("Got an error: %s", err)
-			continue
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
 
-		err = handleMessage(method, contents, logger, &state)
+		os.Exit(serve(conn, sessionOptions))
+	}
+
+	server, err := transport.Listen(*listen)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer server.Close()
+
+	logger.Info("listening for clients", "address", *listen)
+
+	var sessions sync.WaitGroup
+	for {
+		conn, err := server.Accept()
 		if err != nil {
-			logger.Printf("error while handling message: %s", err)
-			continue
+			if !errors.Is(err, net.ErrClosed) {
+				logger.Error("unable to accept connection", "error", err)
+			}
+			break
 		}
 
+		sessions.Add(1)
+		go func() {
+			defer sessions.Done()
+			serve(conn, sessionOptions)
+		}()
 	}
+
+	sessions.Wait()
 }
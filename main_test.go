@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// replays the sessions recorded with gdx record and checks the server still replies the same way
+func TestReplaySessions(t *testing.T) {
+	sessions, err := filepath.Glob(filepath.Join("testdata", "sessions", "*.jsonl"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(sessions) == 0 {
+		t.Fatal("no recorded sessions found")
+	}
+
+	sessionOptions := options{
+		logHandler: slog.NewTextHandler(io.Discard, nil),
+		logLevel:   slog.LevelInfo,
+	}
+
+	for _, filename := range sessions {
+		t.Run(filepath.Base(filename), func(t *testing.T) {
+			mismatches, err := replaySession(filename, sessionOptions, 5*time.Second)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, mismatch := range mismatches {
+				t.Error(mismatch)
+			}
+		})
+	}
+}
+
+func TestProjectSet(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "project.godot")
+	contents := "config_version=5\n\n[application]\n\nconfig/name=\"Game\" ; the title\n"
+	if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := [][]string{
+		{"set", "--project", filename, "--string", "application/config/name", "Platformer"},
+		{"set", "--project", filename, "display/window/size/viewport_width", "1920"},
+		{"set", "--project", filename, "config_version", "5"},
+	}
+
+	for _, args := range commands {
+		if code := project(args); code != 0 {
+			t.Fatalf("expected %q to succeed, exited with %d", args, code)
+		}
+	}
+
+	if code := project([]string{"set", "--project", filename, "application/config/name", "Vector2("}); code != 2 {
+		t.Errorf("expected an invalid value to exit with 2, got %d", code)
+	}
+
+	actual, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "config_version=5\n\n[application]\n\nconfig/name=\"Platformer\" ; the title\n\n[display]\n\nwindow/size/viewport_width=1920\n"
+	if string(actual) != expected {
+		t.Errorf("expected %q, got %q", expected, string(actual))
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"gdx/analysis"
+	"gdx/analysis/variant"
+)
+
+// edits project.godot from the command line
+func project(args []string) int {
+	if len(args) > 0 && args[0] == "set" {
+		return projectSet(args[1:])
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: gdx project set [flags] setting value")
+	return 2
+}
+
+// sets a project setting such as application/config/name, keeping the rest of the file as it was
+func projectSet(args []string) int {
+	flags := flag.NewFlagSet("project set", flag.ExitOnError)
+	filename := flags.String("project", "project.godot", "The project file to edit")
+	plain := flags.Bool("string", false, "Sets the value as a string instead of parsing it as a Godot value")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: gdx project set [flags] setting value")
+		fmt.Fprintln(flags.Output(), "The value is written like in project.godot, such as '\"My Game\"' or 'Vector2i(1920, 1080)'")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	var value any = flags.Arg(1)
+	if !*plain {
+		parsed, err := variant.Parse(flags.Arg(1))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid value: %s\n", err)
+			return 2
+		}
+		value = parsed
+	}
+
+	contents, err := os.ReadFile(*filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	document, errors := analysis.NewConfigDocument(contents)
+	if len(errors) > 0 {
+		for _, err := range errors {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *filename, err)
+		}
+		return 1
+	}
+
+	// settings without a section, such as config_version, are at the top of the file
+	section, key, found := strings.Cut(flags.Arg(0), "/")
+	if !found {
+		section, key = "", flags.Arg(0)
+	}
+
+	if err := document.Set(section, key, value); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := document.Save(*filename); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to save %s: %s\n", *filename, err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gdx/session"
+	"gdx/transport"
+)
+
+// runs a session over stdin and stdout like the server normally does,
+// recording every message sent and received to a file
+func record(args []string) int {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	out := flags.String("out", "session.jsonl", "The file to record the session to")
+	serverOptions := addServerFlags(flags)
+	flags.Parse(args)
+
+	sessionOptions, err := serverOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create recording: %s\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	conn, err := transport.Stdio().Accept()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	sessionOptions.recorder = session.NewRecorder(file)
+	code := serve(conn, sessionOptions)
+
+	if err := sessionOptions.recorder.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to record session: %s\n", err)
+		return 1
+	}
+
+	return code
+}
+
+// replays the messages a client sent in a recorded session and compares the
+// server's replies to the ones that were recorded
+func replay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 2*time.Second, "How long to wait for each reply")
+	serverOptions := addServerFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: gdx replay [flags] session.jsonl")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	sessionOptions, err := serverOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	mismatches, err := replaySession(flags.Arg(0), sessionOptions, *timeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, mismatch := range mismatches {
+		fmt.Print(mismatch)
+	}
+
+	if len(mismatches) > 0 {
+		fmt.Printf("%d replies differ from the recording\n", len(mismatches))
+		return 1
+	}
+
+	return 0
+}
+
+func replaySession(filename string, sessionOptions options, timeout time.Duration) ([]session.Mismatch, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := session.ReadEntries(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", filename, err)
+	}
+
+	return session.Replay(entries, func(conn io.ReadWriteCloser) int {
+		return serve(conn, sessionOptions)
+	}, timeout)
+}
@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// an error returned in a response, the codes are defined by JSON-RPC and the LSP spec
+type ResponseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    any    `json:"data,omitempty"`
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// the ID of a request whose response can't be matched to a request, such as
+// one that couldn't be parsed
+var NullID = json.RawMessage("null")
+
+// a response from the client to a request sent with Call
+type Response struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *ResponseError  `json:"error"`
+}
+
+type request struct {
+	RPC    string          `json:"jsonrpc"`
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params any             `json:"params,omitempty"`
+}
+
+type notification struct {
+	RPC    string `json:"jsonrpc"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+type resultResponse struct {
+	RPC    string          `json:"jsonrpc"`
+	ID     json.RawMessage `json:"id"`
+	Result any             `json:"result"`
+}
+
+type errorResponse struct {
+	RPC   string          `json:"jsonrpc"`
+	ID    json.RawMessage `json:"id"`
+	Error *ResponseError  `json:"error"`
+}
+
+// a connection to a client. Messages are framed and written one at a time so
+// handlers running concurrently can share a connection without interleaving
+type Conn struct {
+	writer  io.Writer
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	onSend func(content []byte)
+	nextID int
+	// requests sent to the client waiting for a response, keyed by their ID
+	pending map[string]chan Response
+}
+
+func NewConn(writer io.Writer) *Conn {
+	return &Conn{
+		writer:  writer,
+		pending: make(map[string]chan Response),
+	}
+}
+
+// encodes a message and writes it to the client
+func (c *Conn) Send(message any) error {
+	encoded, err := EncodeMessage(message)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	onSend := c.onSend
+	c.mu.Unlock()
+
+	// called before writing, and without holding a lock so the hook can send
+	// messages itself, so anything it records comes before the client can reply
+	if onSend != nil {
+		_, content, _ := strings.Cut(encoded, "\r\n\r\n")
+		onSend([]byte(content))
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, err = io.WriteString(c.writer, encoded)
+	return err
+}
+
+// sets a function called with the content of every message before it's sent
+func (c *Conn) OnSend(hook func(content []byte)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onSend = hook
+}
+
+// replies to a request with its result
+func (c *Conn) Reply(id json.RawMessage, result any) error {
+	return c.Send(resultResponse{RPC: "2.0", ID: id, Result: result})
+}
+
+// replies to a request with an error
+func (c *Conn) ReplyError(id json.RawMessage, err *ResponseError) error {
+	return c.Send(errorResponse{RPC: "2.0", ID: id, Error: err})
+}
+
+// sends a notification, which the client doesn't respond to
+func (c *Conn) Notify(method string, params any) error {
+	return c.Send(notification{RPC: "2.0", Method: method, Params: params})
+}
+
+// sends a request to the client and waits for its response. The response's
+// result is decoded into result unless it is nil, error responses are
+// returned as a *ResponseError. Responses are delivered by HandleResponse so
+// this can't be called from the goroutine reading messages
+func (c *Conn) Call(ctx context.Context, method string, params any, result any) error {
+	c.mu.Lock()
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	responses := make(chan Response, 1)
+	c.pending[id] = responses
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.Send(request{RPC: "2.0", ID: json.RawMessage(id), Method: method, Params: params}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case response := <-responses:
+		if response.Error != nil {
+			return response.Error
+		}
+
+		if result == nil || len(response.Result) == 0 {
+			return nil
+		}
+
+		return json.Unmarshal(response.Result, result)
+	}
+}
+
+// passes a response read from the client to the Call waiting for it
+func (c *Conn) HandleResponse(content []byte) error {
+	var response Response
+	if err := json.Unmarshal(content, &response); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	responses, ok := c.pending[string(response.ID)]
+	c.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("received a response to unknown request %s", response.ID)
+	}
+
+	// a duplicate response is dropped rather than blocking the reader
+	select {
+	case responses <- response:
+	default:
+	}
+
+	return nil
+}
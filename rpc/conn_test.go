@@ -0,0 +1,138 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gdx/rpc"
+)
+
+func TestConnMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		send     func(conn *rpc.Conn) error
+		expected string
+	}{
+		{
+			name:     "Reply",
+			send:     func(conn *rpc.Conn) error { return conn.Reply(json.RawMessage("1"), []string{"a"}) },
+			expected: `{"jsonrpc":"2.0","id":1,"result":["a"]}`,
+		},
+		{
+			name:     "Reply without a result",
+			send:     func(conn *rpc.Conn) error { return conn.Reply(json.RawMessage(`"abc"`), nil) },
+			expected: `{"jsonrpc":"2.0","id":"abc","result":null}`,
+		},
+		{
+			name: "Error reply",
+			send: func(conn *rpc.Conn) error {
+				return conn.ReplyError(json.RawMessage("3"), &rpc.ResponseError{Code: -32601, Message: "method not found"})
+			},
+			expected: `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"method not found"}}`,
+		},
+		{
+			name:     "Notification",
+			send:     func(conn *rpc.Conn) error { return conn.Notify("window/logMessage", map[string]int{"type": 3}) },
+			expected: `{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":3}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+			if err := test.send(rpc.NewConn(&output)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(test.expected), test.expected)
+			if output.String() != expected {
+				t.Errorf("expected %q, got %q", expected, output.String())
+			}
+		})
+	}
+}
+
+func TestConnConcurrentWrites(t *testing.T) {
+	var output bytes.Buffer
+	conn := rpc.NewConn(&output)
+
+	var wait sync.WaitGroup
+	for i := range 50 {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			conn.Notify("test", strings.Repeat("x", 1000+i))
+		}()
+	}
+	wait.Wait()
+
+	// every frame has to decode on its own if writes didn't interleave
+	data := output.Bytes()
+	for count := 0; len(data) > 0; count++ {
+		advance, token, err := rpc.Split(data, false)
+		if err != nil || token == nil {
+			t.Fatalf("unable to split frame %d: %v", count, err)
+		}
+
+		if _, _, err := rpc.DecodeMessage(token, nil); err != nil {
+			t.Fatalf("unable to decode frame %d: %v", count, err)
+		}
+
+		data = data[advance:]
+	}
+}
+
+func TestConnCall(t *testing.T) {
+	reader, writer := io.Pipe()
+	conn := rpc.NewConn(writer)
+
+	// acts as the client, answering the first request and failing the second
+	go func() {
+		buffer := make([]byte, 4096)
+		for _, response := range []string{
+			`{"jsonrpc":"2.0","id":1,"result":{"value":42}}`,
+			`{"jsonrpc":"2.0","id":2,"error":{"code":-32603,"message":"failed"}}`,
+		} {
+			reader.Read(buffer)
+			conn.HandleResponse([]byte(response))
+		}
+	}()
+
+	var result struct{ Value int }
+	if err := conn.Call(context.Background(), "workspace/configuration", nil, &result); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Value != 42 {
+		t.Errorf("expected 42, got %d", result.Value)
+	}
+
+	var responseError *rpc.ResponseError
+	err := conn.Call(context.Background(), "client/registerCapability", nil, nil)
+	if !errors.As(err, &responseError) || responseError.Code != -32603 {
+		t.Errorf("expected a response error, got %v", err)
+	}
+}
+
+func TestConnCallCancelled(t *testing.T) {
+	conn := rpc.NewConn(io.Discard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := conn.Call(ctx, "workspace/configuration", nil, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+
+	// the cancelled call is no longer waiting for a response
+	if err := conn.HandleResponse([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`)); err == nil {
+		t.Errorf("expected an error for a response to an unknown request")
+	}
+}
@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// the blank line between a message's header and its content
+var headerSeparator = []byte("\r\n\r\n")
+
+var contentLengthName = []byte("content-length:")
+
+// returned for a header without a valid Content-Length
+var ErrInvalidHeader = errors.New("invalid message header")
+
+// splits a header line into its name and value. Names are compared
+// case-insensitively so the name is returned in lowercase
+func parseHeaderLine(line []byte) (string, string, bool) {
+	name, value, found := bytes.Cut(line, []byte{':'})
+	if !found {
+		return "", "", false
+	}
+
+	name = bytes.TrimSpace(name)
+	if len(name) == 0 {
+		return "", "", false
+	}
+
+	for _, c := range name {
+		if !isHeaderNameChar(c) {
+			return "", "", false
+		}
+	}
+
+	return strings.ToLower(string(name)), string(bytes.TrimSpace(value)), true
+}
+
+func isHeaderNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// reads the Content-Length from a message's header. Other headers such as
+// Content-Type are allowed but ignored
+func parseHeader(header []byte) (int, error) {
+	contentLength := -1
+
+	for _, line := range bytes.Split(header, []byte{'\n'}) {
+		line = bytes.TrimSuffix(line, []byte{'\r'})
+
+		name, value, ok := parseHeaderLine(line)
+		if !ok {
+			return 0, fmt.Errorf("%w: malformed header line %q", ErrInvalidHeader, line)
+		}
+
+		if name != "content-length" {
+			continue
+		}
+
+		length, err := parseContentLength(value)
+		if err != nil {
+			return 0, err
+		}
+
+		if contentLength != -1 && contentLength != length {
+			return 0, fmt.Errorf("%w: conflicting Content-Length headers", ErrInvalidHeader)
+		}
+		contentLength = length
+	}
+
+	if contentLength == -1 {
+		return 0, fmt.Errorf("%w: missing Content-Length", ErrInvalidHeader)
+	}
+
+	return contentLength, nil
+}
+
+func parseContentLength(value string) (int, error) {
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 {
+		return 0, fmt.Errorf("%w: invalid Content-Length %q", ErrInvalidHeader, value)
+	}
+
+	return length, nil
+}
+
+// finds where a Content-Length header starts in a line that may have garbage before it
+func contentLengthIndex(line []byte) int {
+	return bytes.Index(bytes.ToLower(line), contentLengthName)
+}
+
+// the number of bytes to skip to get past garbage at the start of data. This
+// is either up to a Content-Length header later in the first line or the
+// whole first line
+func resync(data []byte) int {
+	line := data
+	if end := bytes.IndexByte(data, '\n'); end != -1 {
+		line = data[:end+1]
+	}
+
+	if index := contentLengthIndex(line); index > 0 {
+		return index
+	}
+
+	return len(line)
+}
@@ -0,0 +1,156 @@
+package rpc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// the largest message a Reader accepts unless told otherwise
+const DefaultMaxMessageSize = 64 << 20
+
+// returned for a message whose Content-Length is over the reader's maximum.
+// The message is skipped so the next one can still be read
+var ErrMessageTooLarge = errors.New("message is too large")
+
+// reads messages from a stream one at a time. Unlike a bufio.Scanner the
+// size of a message is only limited by MaxSize. Lines that aren't part of a
+// valid header are skipped until the start of the next message
+type Reader struct {
+	reader  *bufio.Reader
+	maxSize int
+}
+
+// creates a reader which rejects messages larger than maxSize bytes, a
+// maxSize of 0 or less uses DefaultMaxMessageSize
+func NewReader(r io.Reader, maxSize int) *Reader {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+
+	return &Reader{
+		reader:  bufio.NewReader(r),
+		maxSize: maxSize,
+	}
+}
+
+// reads the content of the next message. ErrInvalidHeader and
+// ErrMessageTooLarge only affect a single message and reading can continue
+// after them, any other error means the stream can't be read anymore
+func (r *Reader) Read() ([]byte, error) {
+	contentLength := -1
+	headers := 0
+
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 {
+			// blank lines between messages are ignored
+			if headers == 0 {
+				continue
+			}
+			break
+		}
+
+		name, value, ok := parseHeaderLine(line)
+		if !ok {
+			// garbage, start again from the next header
+			contentLength, headers = -1, 0
+
+			index := contentLengthIndex(line)
+			if index <= 0 {
+				continue
+			}
+
+			name, value, ok = parseHeaderLine(line[index:])
+			if !ok {
+				continue
+			}
+		}
+
+		headers++
+		if name != "content-length" {
+			continue
+		}
+
+		length, err := parseContentLength(value)
+		if err != nil {
+			r.skipHeader()
+			return nil, err
+		}
+
+		if contentLength != -1 && contentLength != length {
+			r.skipHeader()
+			return nil, fmt.Errorf("%w: conflicting Content-Length headers", ErrInvalidHeader)
+		}
+		contentLength = length
+	}
+
+	if contentLength == -1 {
+		return nil, fmt.Errorf("%w: missing Content-Length", ErrInvalidHeader)
+	}
+
+	if contentLength > r.maxSize {
+		if _, err := io.CopyN(io.Discard, r.reader, int64(contentLength)); err != nil {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %d bytes is over the limit of %d", ErrMessageTooLarge, contentLength, r.maxSize)
+	}
+
+	content := make([]byte, contentLength)
+	if _, err := io.ReadFull(r.reader, content); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// reads a line without its line ending. Lines too long to be a header are
+// skipped and returned as a line that won't parse
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.reader.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		for errors.Is(err, bufio.ErrBufferFull) {
+			_, err = r.reader.ReadSlice('\n')
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return []byte{0}, nil
+	}
+
+	if err != nil {
+		// a final line without a line ending can't start a message
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	line = bytes.TrimSuffix(line, []byte{'\n'})
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+
+	// the line is only valid until the next read
+	return bytes.Clone(line), nil
+}
+
+// skips the rest of a header whose Content-Length can't be trusted
+func (r *Reader) skipHeader() {
+	for {
+		line, err := r.readLine()
+		if err != nil || len(line) == 0 {
+			return
+		}
+	}
+}
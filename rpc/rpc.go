@@ -6,31 +6,69 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"strconv"
 )
 
+// the fields shared by every JSON-RPC message, used to tell requests,
+// notifications and responses apart
 type BaseMessage struct {
-	Method string `json:"method"`
+	RPC    string          `json:"jsonrpc"`
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
 }
 
-func Split(data []byte, _ bool) (advance int, token []byte, err error) {
-	header, content, found := bytes.Cut(data, []byte{'\r', '\n', '\r', '\n'})
+// requests have a method and an ID, which is either a string or a number
+func (m BaseMessage) IsRequest() bool {
+	return m.Method != "" && m.hasID()
+}
+
+// notifications have a method but no ID and are never responded to
+func (m BaseMessage) IsNotification() bool {
+	return m.Method != "" && !m.hasID()
+}
+
+// responses have an ID but no method
+func (m BaseMessage) IsResponse() bool {
+	return m.Method == "" && m.hasID()
+}
+
+func (m BaseMessage) hasID() bool {
+	return len(m.ID) > 0 && string(m.ID) != "null"
+}
+
+// reads the base fields of a message's JSON content
+func ParseMessage(content []byte) (BaseMessage, error) {
+	var message BaseMessage
+	err := json.Unmarshal(content, &message)
+
+	return message, err
+}
+
+// a bufio.SplitFunc that splits a stream into whole messages, headers
+// included. Data that isn't a valid header is skipped a line at a time until
+// the next message. Scanners limit the size of a token so Reader should be
+// used for messages that may be large
+func Split(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	header, content, found := bytes.Cut(data, headerSeparator)
 	if !found {
+		if atEOF && len(data) > 0 {
+			return len(data), nil, nil
+		}
 		return 0, nil, nil
 	}
 
-	// Content-Length: <number>
-	contentLengthBytes := header[len("Content-Length: "):]
-	contentLength, err := strconv.Atoi(string(contentLengthBytes))
+	contentLength, err := parseHeader(header)
 	if err != nil {
-		return 0, nil, err
+		return resync(data), nil, nil
 	}
 
 	if len(content) < contentLength {
+		if atEOF {
+			return len(data), nil, nil
+		}
 		return 0, nil, nil
 	}
 
-	totalLength := len(header) + 4 + contentLength
+	totalLength := len(header) + len(headerSeparator) + contentLength
 	return totalLength, data[:totalLength], nil
 }
 
@@ -48,22 +86,24 @@ func EncodeMessage(toBeEncoded any) (string, error) {
 
 // decodes an RPC message returning which method it's using, the contents of the message, and an error if there is one
 func DecodeMessage(msg []byte, logger *log.Logger) (string, []byte, error) {
-	header, content, found := bytes.Cut(msg, []byte{'\r', '\n', '\r', '\n'})
+	header, content, found := bytes.Cut(msg, headerSeparator)
 	if !found {
 		return "", nil, errors.New("unable to find seperator in message")
 	}
 
-	contentLengthBytes := header[len("Content-Length: "):]
-	contentLength, err := strconv.Atoi(string(contentLengthBytes))
+	contentLength, err := parseHeader(header)
 	if err != nil {
 		return "", nil, err
 	}
 
+	if len(content) < contentLength {
+		return "", nil, fmt.Errorf("message is shorter than its Content-Length of %d", contentLength)
+	}
+
 	var baseMessage BaseMessage
 	if err := json.Unmarshal(content[:contentLength], &baseMessage); err != nil {
 		return "", nil, err
 	}
 
 	return baseMessage.Method, content[:contentLength], nil
-
 }
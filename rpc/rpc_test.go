@@ -2,7 +2,12 @@ package rpc_test
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"reflect"
+	"strings"
 	"testing"
 
 	"gdx/rpc"
@@ -34,3 +39,239 @@ func TestDecodeMessage(t *testing.T) {
 		t.Errorf("expected %q, got %q", "foo", method)
 	}
 }
+
+func TestParseMessage(t *testing.T) {
+	tests := []struct {
+		content      string
+		request      bool
+		notification bool
+		response     bool
+	}{
+		{content: `{"jsonrpc":"2.0","id":1,"method":"initialize"}`, request: true},
+		{content: `{"jsonrpc":"2.0","id":"a1","method":"initialize"}`, request: true},
+		{content: `{"jsonrpc":"2.0","method":"initialized"}`, notification: true},
+		{content: `{"jsonrpc":"2.0","id":null,"method":"initialized"}`, notification: true},
+		{content: `{"jsonrpc":"2.0","id":1,"result":null}`, response: true},
+		{content: `{"jsonrpc":"2.0","id":"a1","error":{"code":-32601,"message":"method not found"}}`, response: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.content, func(t *testing.T) {
+			message, err := rpc.ParseMessage([]byte(test.content))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			actual := []bool{message.IsRequest(), message.IsNotification(), message.IsResponse()}
+			expected := []bool{test.request, test.notification, test.response}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("expected %v, got %v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+		errors   []error
+	}{
+		{
+			name:     "Content-Length only",
+			input:    "Content-Length: 5\r\n\r\nhello",
+			expected: []string{"hello"},
+			errors:   []error{nil},
+		},
+		{
+			name:     "Other headers in any order",
+			input:    "Content-Type: application/vscode-jsonrpc; charset=utf-8\r\nContent-Length: 5\r\n\r\nhello",
+			expected: []string{"hello"},
+			errors:   []error{nil},
+		},
+		{
+			name:     "Lowercase names and extra whitespace",
+			input:    "content-length:5  \r\n\r\nhello",
+			expected: []string{"hello"},
+			errors:   []error{nil},
+		},
+		{
+			name:     "Garbage before a message",
+			input:    "{\"broken\": true}\r\nnot a header\r\nContent-Length: 5\r\n\r\nhello",
+			expected: []string{"hello"},
+			errors:   []error{nil},
+		},
+		{
+			name:     "Garbage on the same line as a header",
+			input:    "}}Content-Length: 5\r\n\r\nhello",
+			expected: []string{"hello"},
+			errors:   []error{nil},
+		},
+		{
+			name:     "Missing Content-Length",
+			input:    "Content-Type: text/plain\r\n\r\nContent-Length: 5\r\n\r\nhello",
+			expected: []string{"", "hello"},
+			errors:   []error{rpc.ErrInvalidHeader, nil},
+		},
+		{
+			name:     "Invalid Content-Length",
+			input:    "Content-Length: -3\r\n\r\nxyz\r\nContent-Length: 5\r\n\r\nhello",
+			expected: []string{"", "hello"},
+			errors:   []error{rpc.ErrInvalidHeader, nil},
+		},
+		{
+			name:     "Too large",
+			input:    "Content-Length: 11\r\n\r\nhello worldContent-Length: 5\r\n\r\nhello",
+			expected: []string{"", "hello"},
+			errors:   []error{rpc.ErrMessageTooLarge, nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reader := rpc.NewReader(strings.NewReader(test.input), 10)
+
+			actual := make([]string, 0)
+			actualErrors := make([]error, 0)
+			for range test.expected {
+				content, err := reader.Read()
+				actual = append(actual, string(content))
+				if err != nil && !errors.Is(err, rpc.ErrInvalidHeader) && !errors.Is(err, rpc.ErrMessageTooLarge) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				actualErrors = append(actualErrors, errors.Unwrap(err))
+			}
+
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("expected %q, got %q", test.expected, actual)
+			}
+
+			if !reflect.DeepEqual(actualErrors, test.errors) {
+				t.Errorf("expected errors %v, got %v", test.errors, actualErrors)
+			}
+
+			if _, err := reader.Read(); err != io.EOF {
+				t.Errorf("expected io.EOF at the end of the input, got %v", err)
+			}
+		})
+	}
+}
+
+func TestReaderLargeMessage(t *testing.T) {
+	content := strings.Repeat("x", 1<<20)
+	reader := rpc.NewReader(strings.NewReader(fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(content), content)), 0)
+
+	actual, err := reader.Read()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(actual) != content {
+		t.Errorf("expected a message of %d bytes, got %d bytes", len(content), len(actual))
+	}
+}
+
+func TestSplitResync(t *testing.T) {
+	data := []byte("garbage\r\ncontent-length: 2\r\nX-Other: 1\r\n\r\n{}Content-Length: 2\r\n\r\n{}")
+
+	actual := make([]string, 0)
+	for len(data) > 0 {
+		advance, token, err := rpc.Split(data, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if advance == 0 {
+			t.Fatalf("no progress splitting %q", data)
+		}
+
+		if token != nil {
+			_, content, err := rpc.DecodeMessage(token, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			actual = append(actual, string(content))
+		}
+
+		data = data[advance:]
+	}
+
+	if !reflect.DeepEqual(actual, []string{"{}", "{}"}) {
+		t.Errorf("expected both messages, got %q", actual)
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	f.Add([]byte("Content-Length: 5\r\n\r\nhello"))
+	f.Add([]byte("content-type: x\r\nCONTENT-LENGTH: 2\r\n\r\n{}"))
+	f.Add([]byte("}}Content-Length: 99999\r\n\r\n"))
+	f.Add([]byte("Content-Length: 1\r\nContent-Length: 2\r\n\r\nab"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		reader := rpc.NewReader(bytes.NewReader(data), 64)
+
+		// every read consumes input so this has to reach the end
+		for range len(data) + 1 {
+			content, err := reader.Read()
+			if errors.Is(err, rpc.ErrInvalidHeader) || errors.Is(err, rpc.ErrMessageTooLarge) {
+				continue
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(content) > 64 {
+				t.Fatalf("read a message of %d bytes, over the maximum", len(content))
+			}
+		}
+
+		t.Fatalf("reader didn't reach the end of %q", data)
+	})
+}
+
+func FuzzReaderResync(f *testing.F) {
+	f.Add([]byte("garbage"), []byte(`{"method":"initialized"}`))
+	f.Add([]byte("{\"id\": 1"), []byte(""))
+
+	f.Fuzz(func(t *testing.T, garbage []byte, content []byte) {
+		// a line with a colon could be a header, which isn't garbage
+		if bytes.ContainsAny(garbage, ":\n") {
+			t.Skip()
+		}
+
+		message := fmt.Sprintf("%s\r\nContent-Length: %d\r\n\r\n%s", garbage, len(content), content)
+		reader := rpc.NewReader(strings.NewReader(message), 0)
+
+		actual, err := reader.Read()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !bytes.Equal(actual, content) {
+			t.Errorf("expected %q, got %q", content, actual)
+		}
+	})
+}
+
+func FuzzSplit(f *testing.F) {
+	f.Add([]byte("Content-Length: 5\r\n\r\nhello"), false)
+	f.Add([]byte("xContent-Length: 1\r\n\r\n"), true)
+
+	f.Fuzz(func(t *testing.T, data []byte, atEOF bool) {
+		advance, token, err := rpc.Split(data, atEOF)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if advance < 0 || advance > len(data) || len(token) > advance {
+			t.Fatalf("invalid split of %q: advance %d, token %q", data, advance, token)
+		}
+
+		// tokens are whole messages so they always have a valid header
+		if token != nil && !bytes.Contains(token, []byte("\r\n\r\n")) {
+			t.Fatalf("split a token without a header: %q", token)
+		}
+	})
+}
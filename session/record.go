@@ -0,0 +1,108 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+// which way a recorded message went, from the server's point of view
+type Direction = string
+
+const (
+	DirectionIn  Direction = "in"
+	DirectionOut Direction = "out"
+)
+
+// a message sent or received during a recorded session. Sessions are stored
+// as JSON lines with one entry per message
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	// the message itself, or a JSON string with the content of a message that isn't valid JSON
+	Message json.RawMessage `json:"message"`
+}
+
+// the content of the message as it was sent or received
+func (e Entry) Content() []byte {
+	var raw string
+	if err := json.Unmarshal(e.Message, &raw); err == nil {
+		return []byte(raw)
+	}
+
+	return e.Message
+}
+
+// writes the messages of a session to w as they are sent and received
+type Recorder struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{encoder: json.NewEncoder(w)}
+}
+
+// records a single message. Messages are recorded as compact JSON so every entry is a single
+// line. Content that isn't valid JSON is kept as a string, since malformed messages are often
+// what a recording is made to reproduce
+func (r *Recorder) Record(direction Direction, content []byte) error {
+	message, err := compact(content)
+	if err != nil {
+		message, _ = json.Marshal(string(content))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.encoder.Encode(Entry{Time: time.Now().UTC(), Direction: direction, Message: message}); err != nil {
+		r.err = err
+		return err
+	}
+
+	return nil
+}
+
+// returns the first error that stopped a message from being recorded
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// reads the entries of a recorded session
+func ReadEntries(r io.Reader) ([]Entry, error) {
+	entries := make([]Entry, 0)
+
+	decoder := json.NewDecoder(r)
+	for {
+		var entry Entry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Direction != DirectionIn && entry.Direction != DirectionOut {
+			return nil, errors.New("invalid direction " + entry.Direction + " in recorded session")
+		}
+
+		entries = append(entries, entry)
+	}
+}
+
+func compact(content []byte) (json.RawMessage, error) {
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, content); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
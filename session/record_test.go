@@ -0,0 +1,48 @@
+package session_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gdx/session"
+)
+
+func TestRecordMalformedMessage(t *testing.T) {
+	var output bytes.Buffer
+	recorder := session.NewRecorder(&output)
+
+	contents := []string{
+		`{"jsonrpc": "2.0", "method": "initialized"}`,
+		`{"jsonrpc":"2.0","method":`,
+	}
+
+	for _, content := range contents {
+		if err := recorder.Record(session.DirectionIn, []byte(content)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := session.ReadEntries(&output)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{
+		`{"jsonrpc":"2.0","method":"initialized"}`,
+		`{"jsonrpc":"2.0","method":`,
+	}
+
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+
+	for i, entry := range entries {
+		if string(entry.Content()) != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], entry.Content())
+		}
+	}
+}
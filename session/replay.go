@@ -0,0 +1,285 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gdx/rpc"
+)
+
+// messages that depend on how the server was configured rather than what it
+// was sent, these are recorded but not compared when replaying
+var ignoredMethods = []string{"window/logMessage", "$/logTrace"}
+
+// runs a server on a connection until the client exits or disconnects
+type ServeFunc func(conn io.ReadWriteCloser) int
+
+// messages sent after one recorded inbound message that don't match the
+// recording, either because the server didn't send them or because they
+// weren't recorded at all
+type Mismatch struct {
+	// the index of the inbound message in the session
+	Step       int
+	Inbound    string
+	Missing    []string
+	Unexpected []string
+}
+
+func (m Mismatch) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "after message %d: %s\n", m.Step, m.Inbound)
+	for _, message := range m.Missing {
+		fmt.Fprintf(&builder, "- %s\n", message)
+	}
+	for _, message := range m.Unexpected {
+		fmt.Fprintf(&builder, "+ %s\n", message)
+	}
+
+	return builder.String()
+}
+
+// a recorded inbound message followed by the messages the server sent before the next one
+type step struct {
+	inbound  []byte
+	expected []string
+}
+
+// feeds the inbound messages of a recorded session to a server one at a time,
+// waiting up to timeout for as many messages as were recorded after it
+// before sending the next one. Requests are handled concurrently so a reply
+// may be sent after a later message, replies are only reported as mismatches
+// if they weren't sent at all or were never recorded
+func Replay(entries []Entry, serve ServeFunc, timeout time.Duration) ([]Mismatch, error) {
+	steps, err := splitSteps(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		serve(server)
+	}()
+
+	sent := newQueue()
+	go func() {
+		defer sent.close()
+
+		reader := rpc.NewReader(client, 0)
+		for {
+			content, err := reader.Read()
+			if errors.Is(err, rpc.ErrInvalidHeader) || errors.Is(err, rpc.ErrMessageTooLarge) {
+				continue
+			}
+
+			if err != nil {
+				return
+			}
+
+			message, err := normalize(content)
+			if err != nil {
+				message = string(content)
+			}
+
+			if !ignored(message) {
+				sent.push(message)
+			}
+		}
+	}()
+
+	actual := make([][]string, len(steps))
+	for i, step := range steps {
+		if _, err := io.WriteString(client, frame(step.inbound)); err != nil {
+			return nil, fmt.Errorf("unable to send message %d: %w", i, err)
+		}
+
+		actual[i] = sent.receive(len(step.expected), timeout)
+	}
+
+	// anything sent after the last message is counted as a reply to it
+	client.Close()
+	<-exited
+	if len(steps) > 0 {
+		actual[len(steps)-1] = append(actual[len(steps)-1], sent.receive(-1, timeout)...)
+	}
+
+	return compare(steps, actual), nil
+}
+
+// finds the messages that were recorded but not sent and the ones sent but not recorded
+func compare(steps []step, actual [][]string) []Mismatch {
+	remaining := make(map[string]int)
+	for _, step := range steps {
+		for _, message := range step.expected {
+			remaining[message]++
+		}
+	}
+
+	unexpected := make([][]string, len(steps))
+	for i, messages := range actual {
+		for _, message := range messages {
+			if remaining[message] > 0 {
+				remaining[message]--
+				continue
+			}
+			unexpected[i] = append(unexpected[i], message)
+		}
+	}
+
+	mismatches := make([]Mismatch, 0)
+	for i, step := range steps {
+		var missing []string
+		for _, message := range step.expected {
+			if remaining[message] > 0 {
+				remaining[message]--
+				missing = append(missing, message)
+			}
+		}
+
+		if len(missing) > 0 || len(unexpected[i]) > 0 {
+			mismatches = append(mismatches, Mismatch{
+				Step:       i,
+				Inbound:    string(step.inbound),
+				Missing:    missing,
+				Unexpected: unexpected[i],
+			})
+		}
+	}
+
+	return mismatches
+}
+
+func splitSteps(entries []Entry) ([]step, error) {
+	steps := make([]step, 0)
+
+	for _, entry := range entries {
+		if entry.Direction == DirectionIn {
+			steps = append(steps, step{inbound: entry.Content(), expected: make([]string, 0)})
+			continue
+		}
+
+		// compared in the same way as the messages the server sends
+		content := entry.Content()
+		message, err := normalize(content)
+		if err != nil {
+			message = string(content)
+		}
+
+		if ignored(message) {
+			continue
+		}
+
+		// messages sent before the client sent anything
+		if len(steps) == 0 {
+			return nil, errors.New("recorded session starts with a message from the server")
+		}
+
+		last := &steps[len(steps)-1]
+		last.expected = append(last.expected, message)
+	}
+
+	return steps, nil
+}
+
+// the messages sent by the server. Messages are queued rather than sent on a
+// channel so the server is never blocked writing while a message is being sent to it
+type queue struct {
+	mu       sync.Mutex
+	messages []string
+	closed   bool
+	// signalled whenever a message is pushed or the queue is closed
+	changed chan struct{}
+}
+
+func newQueue() *queue {
+	return &queue{changed: make(chan struct{}, 1)}
+}
+
+func (q *queue) push(message string) {
+	q.mu.Lock()
+	q.messages = append(q.messages, message)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *queue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *queue) signal() {
+	select {
+	case q.changed <- struct{}{}:
+	default:
+	}
+}
+
+// waits for count messages or until timeout has passed without one arriving.
+// A negative count waits for the queue to be closed
+func (q *queue) receive(count int, timeout time.Duration) []string {
+	actual := make([]string, 0)
+
+	for {
+		q.mu.Lock()
+		for len(q.messages) > 0 && len(actual) != count {
+			actual = append(actual, q.messages[0])
+			q.messages = q.messages[1:]
+		}
+		closed := q.closed && len(q.messages) == 0
+		q.mu.Unlock()
+
+		if len(actual) == count || closed {
+			return actual
+		}
+
+		select {
+		case <-q.changed:
+		case <-time.After(timeout):
+			return actual
+		}
+	}
+}
+
+func ignored(message string) bool {
+	var base rpc.BaseMessage
+	if err := json.Unmarshal([]byte(message), &base); err != nil {
+		return false
+	}
+
+	return slices.Contains(ignoredMethods, base.Method)
+}
+
+// rewrites a message with its object keys sorted so equal messages compare equal
+func normalize(content []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	return string(normalized), nil
+}
+
+func frame(content []byte) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(content), content)
+}
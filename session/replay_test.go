@@ -0,0 +1,73 @@
+package session_test
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gdx/rpc"
+	"gdx/session"
+)
+
+// a server that replies to every request with its method, or nothing for the method "silent"
+func echo(conn io.ReadWriteCloser) int {
+	defer conn.Close()
+
+	client := rpc.NewConn(conn)
+	reader := rpc.NewReader(conn, 0)
+	for {
+		content, err := reader.Read()
+		if err != nil {
+			return 0
+		}
+
+		message, _ := rpc.ParseMessage(content)
+		if message.IsRequest() && message.Method != "silent" {
+			client.Reply(message.ID, message.Method)
+		}
+		client.Notify("window/logMessage", map[string]any{"type": 3, "message": "not compared"})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	recording := strings.Join([]string{
+		`{"time":"2026-01-01T00:00:00Z","direction":"in","message":{"jsonrpc":"2.0","id":1,"method":"first"}}`,
+		`{"time":"2026-01-01T00:00:01Z","direction":"out","message":{"result":"first","jsonrpc":"2.0","id":1}}`,
+		`{"time":"2026-01-01T00:00:02Z","direction":"in","message":{"jsonrpc":"2.0","id":2,"method":"second"}}`,
+		`{"time":"2026-01-01T00:00:03Z","direction":"out","message":{"jsonrpc":"2.0","id":2,"result":"changed"}}`,
+		`{"time":"2026-01-01T00:00:04Z","direction":"in","message":{"jsonrpc":"2.0","id":3,"method":"silent"}}`,
+		`{"time":"2026-01-01T00:00:05Z","direction":"out","message":{"jsonrpc":"2.0","id":3,"result":null}}`,
+	}, "\n")
+
+	entries, err := session.ReadEntries(strings.NewReader(recording))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mismatches, err := session.Replay(entries, echo, 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []session.Mismatch{
+		{
+			Step:       1,
+			Inbound:    `{"jsonrpc":"2.0","id":2,"method":"second"}`,
+			Missing:    []string{`{"id":2,"jsonrpc":"2.0","result":"changed"}`},
+			Unexpected: []string{`{"id":2,"jsonrpc":"2.0","result":"second"}`},
+		},
+		{
+			Step:    2,
+			Inbound: `{"jsonrpc":"2.0","id":3,"method":"silent"}`,
+			Missing: []string{`{"id":3,"jsonrpc":"2.0","result":null}`},
+		},
+	}
+
+	if !reflect.DeepEqual(mismatches, expected) {
+		actual, _ := json.MarshalIndent(mismatches, "", "  ")
+		t.Errorf("unexpected mismatches: %s", actual)
+	}
+}
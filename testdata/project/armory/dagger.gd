@@ -0,0 +1,3 @@
+extends "../weapon.gd"
+
+@export var reach := 0.5
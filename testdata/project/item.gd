@@ -0,0 +1,7 @@
+class_name Item
+extends Resource
+
+@export var title: String
+@export_range(0, 100) var price := 10
+var owner_count := 0
+static var created := 0
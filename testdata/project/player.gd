@@ -0,0 +1,6 @@
+extends CharacterBody2D
+
+func _physics_process(delta):
+	if Input.is_action_just_pressed("jump"):
+		velocity.y = -400
+	move_and_slide()
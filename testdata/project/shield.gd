@@ -0,0 +1,4 @@
+class_name Shield
+extends Item
+
+@export var armor := 2
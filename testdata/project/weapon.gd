@@ -0,0 +1,4 @@
+class_name Weapon
+extends "res://item.gd"
+
+@export var damage := 1.0
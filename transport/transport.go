@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// a source of client connections. Each connection is a separate editor session
+type Transport interface {
+	// waits for the next connection, returning net.ErrClosed once the transport is closed
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// listens for connections on an address such as tcp://127.0.0.1:6005 or unix:///tmp/gdx.sock
+func Listen(address string) (Transport, error) {
+	location, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address %q: %s", address, err)
+	}
+
+	var listener net.Listener
+	switch location.Scheme {
+	case "tcp":
+		listener, err = net.Listen("tcp", location.Host)
+	case "unix":
+		// unix://relative/path puts the first part of the path in the host
+		listener, err = net.Listen("unix", location.Host+location.Path)
+	default:
+		return nil, fmt.Errorf("unsupported listen address %q, expected tcp://host:port or unix:///path", address)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &socket{Listener: listener}, nil
+}
+
+type socket struct {
+	net.Listener
+}
+
+func (s *socket) Accept() (io.ReadWriteCloser, error) {
+	return s.Listener.Accept()
+}
+
+// a transport with a single connection over the process's stdin and stdout
+func Stdio() Transport {
+	return NewStream(os.Stdin, os.Stdout)
+}
+
+// a transport with a single connection reading from r and writing to w.
+// Accept returns the connection once and then waits for it to be closed
+func NewStream(r io.Reader, w io.Writer) Transport {
+	return &stream{
+		conn:   &streamConn{Reader: r, Writer: w},
+		closed: make(chan struct{}),
+	}
+}
+
+type stream struct {
+	conn     *streamConn
+	accepted bool
+	closed   chan struct{}
+	once     sync.Once
+}
+
+type streamConn struct {
+	io.Reader
+	io.Writer
+	stream *stream
+}
+
+func (s *stream) Accept() (io.ReadWriteCloser, error) {
+	select {
+	case <-s.closed:
+		return nil, net.ErrClosed
+	default:
+	}
+
+	if !s.accepted {
+		s.accepted = true
+		s.conn.stream = s
+		return s.conn, nil
+	}
+
+	<-s.closed
+	return nil, net.ErrClosed
+}
+
+func (s *stream) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	return nil
+}
+
+// closing the only connection closes the whole transport
+func (c *streamConn) Close() error {
+	return c.stream.Close()
+}
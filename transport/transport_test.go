@@ -0,0 +1,107 @@
+package transport_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gdx/transport"
+)
+
+// checks that a connection accepted by the transport can exchange data with the client
+func roundTrip(t *testing.T, server transport.Transport, client net.Conn) {
+	t.Helper()
+
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := client.Write([]byte("ping")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buffer := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buffer); err != nil || string(buffer) != "ping" {
+		t.Fatalf("expected ping, got %q (%v)", buffer, err)
+	}
+
+	if _, err := conn.Write([]byte("pong")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := io.ReadFull(client, buffer); err != nil || string(buffer) != "pong" {
+		t.Fatalf("expected pong, got %q (%v)", buffer, err)
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	server, err := transport.Listen("tcp://127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer server.Close()
+
+	address := server.(interface{ Addr() net.Addr }).Addr()
+	client, err := net.Dial("tcp", address.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	roundTrip(t, server, client)
+}
+
+func TestListenUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gdx.sock")
+
+	server, err := transport.Listen("unix://" + path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer server.Close()
+
+	client, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	roundTrip(t, server, client)
+}
+
+func TestListenInvalidAddress(t *testing.T) {
+	for _, address := range []string{"udp://127.0.0.1:6005", "localhost:6005"} {
+		if _, err := transport.Listen(address); err == nil || !strings.Contains(err.Error(), "unsupported") {
+			t.Errorf("expected an unsupported address error for %s, got %v", address, err)
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	var output bytes.Buffer
+	server := transport.NewStream(strings.NewReader("ping"), &output)
+
+	conn, err := server.Accept()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	input, _ := io.ReadAll(conn)
+	conn.Write([]byte("pong"))
+
+	if string(input) != "ping" || output.String() != "pong" {
+		t.Errorf("expected ping and pong, got %q and %q", input, output.String())
+	}
+
+	// the transport only has one connection, closing it closes the transport
+	conn.Close()
+	if _, err := server.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("expected net.ErrClosed, got %v", err)
+	}
+}